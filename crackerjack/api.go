@@ -0,0 +1,603 @@
+package crackerjack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// API is the set of CrackerJack operations used by the TUI and CLI. *Client
+// implements it; callers can substitute their own implementation, e.g. a
+// mock in tests.
+type API interface {
+	// Route describes how requests reach the server.
+	Route() string
+
+	CreateSession(ctx context.Context, name string) (int, error)
+	GetAllSessions(ctx context.Context) ([]Session, error)
+	GetSession(ctx context.Context, id int) (*Session, error)
+	RenameSession(ctx context.Context, id int, name string) error
+	DeleteSession(ctx context.Context, id int) error
+	UploadHashes(ctx context.Context, sessionID int, hashes string, containsUsernames bool) error
+	UploadHashesFrom(ctx context.Context, sessionID int, r io.Reader, opts HashUpload) (int64, error)
+	UploadedHashBytes(ctx context.Context, sessionID int) (int64, error)
+	SetHashType(ctx context.Context, sessionID int, hashType string) error
+	SetMode(ctx context.Context, sessionID int, mode string) error
+	SetWordlist(ctx context.Context, sessionID int, wordlist string, scope WordlistScope) error
+	SetCombinator(ctx context.Context, sessionID int, combinator Combinator) error
+	SetRule(ctx context.Context, sessionID int, rule string) error
+	SetMask(ctx context.Context, sessionID int, mask string) error
+	SetMaskAttack(ctx context.Context, sessionID int, attack MaskAttack) error
+	StartJob(ctx context.Context, sessionID int) error
+	StopJob(ctx context.Context, sessionID int) error
+	PauseJob(ctx context.Context, sessionID int) error
+	ResumeJob(ctx context.Context, sessionID int) error
+	RestoreJob(ctx context.Context, sessionID int) error
+	GetState(ctx context.Context, sessionID int) (*SessionState, error)
+	DownloadResults(ctx context.Context, sessionID int, kind ResultsKind) (string, error)
+	GetHashTypes(ctx context.Context) ([]HashType, error)
+	GetWordlists(ctx context.Context) ([]FileInfo, error)
+	GetRules(ctx context.Context) ([]FileInfo, error)
+	UploadWordlist(ctx context.Context, name string, r io.Reader) error
+	UploadSessionWordlist(ctx context.Context, sessionID int, name string, r io.Reader) error
+	UploadRule(ctx context.Context, name string, r io.Reader) error
+}
+
+var _ API = (*Client)(nil)
+
+type SessionHashcat struct {
+	Mode          int      `json:"mode"`
+	HashType      string   `json:"hashType"`
+	Wordlist      string   `json:"wordlist"`
+	Rule          string   `json:"rule"`
+	Mask          string   `json:"mask"`
+	LeftWordlist  string   `json:"leftWordlist"`
+	RightWordlist string   `json:"rightWordlist"`
+	LeftRule      string   `json:"leftRule"`
+	RightRule     string   `json:"rightRule"`
+	Masks         []string `json:"masks"`
+	Charsets      []string `json:"charsets"`
+	Increment     bool     `json:"increment"`
+	IncrementMin  int      `json:"incrementMin"`
+	IncrementMax  int      `json:"incrementMax"`
+
+	// Speed is the last reported cracking speed in hashes per second.
+	Speed            float64 `json:"speed"`
+	State            int     `json:"state"`
+	StateDescription string  `json:"state_description"`
+	Progress         float64 `json:"progress"`
+	CrackedPasswords int     `json:"crackedPasswords"`
+	AllPasswords     int     `json:"allPasswords"`
+}
+
+type Session struct {
+	ID       int            `json:"id"`
+	Name     string         `json:"name"`
+	Username string         `json:"username"`
+	Created  string         `json:"created_at"`
+	Hashcat  SessionHashcat `json:"hashcat"`
+
+	// ContainsUsernames reports whether the hashes were uploaded as
+	// "username:hash" lines, in which case results are "username:hash:plain".
+	ContainsUsernames bool `json:"contains_usernames"`
+}
+
+// createdLayouts are the timestamp formats the server has been seen to use.
+var createdLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999",
+}
+
+// CreatedAt parses the session's creation time. It reports false if the
+// server did not send one or it is in an unknown format.
+func (s Session) CreatedAt() (time.Time, bool) {
+	for _, layout := range createdLayouts {
+		if t, err := time.Parse(layout, s.Created); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+type NewSessionResponse struct {
+	ID int `json:"id"`
+}
+
+func (c *Client) CreateSession(ctx context.Context, name string) (int, error) {
+	payload := map[string]string{"name": name}
+	body, _ := json.Marshal(payload)
+	resp, err := c.apiRequest(ctx, opControl, "POST", "/sessions", bytes.NewBuffer(body))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp, "creating session"); err != nil {
+		return 0, err
+	}
+
+	var sessionResp NewSessionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&sessionResp); err != nil {
+		return 0, err
+	}
+	return sessionResp.ID, nil
+}
+
+func (c *Client) GetAllSessions(ctx context.Context) ([]Session, error) {
+	resp, err := c.retryRequest(ctx, opPoll, "GET", "/sessions", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "getting sessions"); err != nil {
+		return nil, err
+	}
+	var sessions []Session
+	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (c *Client) GetSession(ctx context.Context, id int) (*Session, error) {
+	endpoint := fmt.Sprintf("/sessions/%d", id)
+	resp, err := c.retryRequest(ctx, opPoll, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, fmt.Sprintf("getting session %d", id)); err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (c *Client) RenameSession(ctx context.Context, id int, name string) error {
+	payload := map[string]string{"name": name}
+	body, _ := json.Marshal(payload)
+	endpoint := fmt.Sprintf("/sessions/%d/name", id)
+	resp, err := c.apiRequest(ctx, opControl, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, fmt.Sprintf("renaming session %d", id)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) DeleteSession(ctx context.Context, id int) error {
+	endpoint := fmt.Sprintf("/sessions/%d", id)
+	resp, err := c.apiRequest(ctx, opControl, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, fmt.Sprintf("deleting session %d", id)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UploadHashes uploads newline-separated hashes. With containsUsernames set,
+// each line is "username:hash" and results map cracked hashes back to users.
+// The hashes are sent in a single request; use UploadHashesFrom for large
+// inputs.
+func (c *Client) UploadHashes(ctx context.Context, sessionID int, hashes string, containsUsernames bool) error {
+	payload := map[string]interface{}{"data": hashes, "contains_usernames": containsUsernames}
+	body, _ := json.Marshal(payload)
+	endpoint := fmt.Sprintf("/hashes/%d/upload", sessionID)
+	resp, err := c.apiRequest(ctx, opTransfer, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "on hash upload"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) SetHashType(ctx context.Context, sessionID int, hashType string) error {
+	payload := map[string]string{"type": hashType}
+	body, _ := json.Marshal(payload)
+	endpoint := fmt.Sprintf("/hashcat/%d/type", sessionID)
+	resp, err := c.apiRequest(ctx, opControl, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "on set hash type"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Attack modes accepted by SetMode.
+const (
+	ModeWordlist           = "wordlist"             // hashcat -a 0
+	ModeCombinator         = "combinator"           // hashcat -a 1: each left word followed by each right word
+	ModeMask               = "mask"                 // hashcat -a 3
+	ModeHybridWordlistMask = "hybrid-wordlist-mask" // hashcat -a 6: each word followed by the mask
+	ModeHybridMaskWordlist = "hybrid-mask-wordlist" // hashcat -a 7: the mask followed by each word
+)
+
+// Modes lists the attack modes in display order.
+var Modes = []string{ModeWordlist, ModeCombinator, ModeMask, ModeHybridWordlistMask, ModeHybridMaskWordlist}
+
+// UsesWordlist reports whether an attack mode needs a wordlist (SetWordlist).
+// Combinator mode uses SetCombinator instead.
+func UsesWordlist(mode string) bool {
+	return mode == ModeWordlist || mode == ModeHybridWordlistMask || mode == ModeHybridMaskWordlist
+}
+
+// UsesMask reports whether an attack mode needs a mask (SetMask).
+func UsesMask(mode string) bool {
+	return mode == ModeMask || mode == ModeHybridWordlistMask || mode == ModeHybridMaskWordlist
+}
+
+// AttackMode returns the SetMode name of the session's hashcat attack mode,
+// or false if the client does not support it.
+func (h SessionHashcat) AttackMode() (string, bool) {
+	switch h.Mode {
+	case 0:
+		return ModeWordlist, true
+	case 1:
+		return ModeCombinator, true
+	case 3:
+		return ModeMask, true
+	case 6:
+		return ModeHybridWordlistMask, true
+	case 7:
+		return ModeHybridMaskWordlist, true
+	}
+	return "", false
+}
+
+func (c *Client) SetMode(ctx context.Context, sessionID int, mode string) error {
+	payload := map[string]string{"mode": mode}
+	body, _ := json.Marshal(payload)
+	endpoint := fmt.Sprintf("/hashcat/%d/mode", sessionID)
+	resp, err := c.apiRequest(ctx, opControl, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "on set mode"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// WordlistScope says where SetWordlist looks up a wordlist by name.
+type WordlistScope string
+
+const (
+	WordlistGlobal  WordlistScope = "global"  // the server's shared wordlist library
+	WordlistSession WordlistScope = "session" // wordlists uploaded to the session itself
+)
+
+func (c *Client) SetWordlist(ctx context.Context, sessionID int, wordlist string, scope WordlistScope) error {
+	payload := map[string]string{"name": wordlist}
+	body, _ := json.Marshal(payload)
+	endpoint := fmt.Sprintf("/wordlists/%d/%s", sessionID, scope)
+	resp, err := c.apiRequest(ctx, opControl, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "on set wordlist"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Combinator selects the two wordlists of a combinator attack. LeftRule and
+// RightRule are optional single hashcat rules applied to the words of each
+// side (hashcat's -j and -k), e.g. "$-" or "c".
+type Combinator struct {
+	LeftWordlist  string `json:"left"`
+	RightWordlist string `json:"right"`
+	LeftRule      string `json:"left_rule,omitempty"`
+	RightRule     string `json:"right_rule,omitempty"`
+}
+
+func (c *Client) SetCombinator(ctx context.Context, sessionID int, combinator Combinator) error {
+	body, _ := json.Marshal(combinator)
+	endpoint := fmt.Sprintf("/wordlists/%d/combinator", sessionID)
+	resp, err := c.apiRequest(ctx, opControl, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "on set combinator wordlists"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) SetRule(ctx context.Context, sessionID int, rule string) error {
+	payload := map[string]string{"name": rule}
+	body, _ := json.Marshal(payload)
+	endpoint := fmt.Sprintf("/rules/%d", sessionID)
+	resp, err := c.apiRequest(ctx, opControl, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "on set rule"); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) SetMask(ctx context.Context, sessionID int, mask string) error {
+	return c.SetMaskAttack(ctx, sessionID, MaskAttack{Mask: mask})
+}
+
+// MaskAttack is the full mask configuration of a mask or hybrid attack.
+type MaskAttack struct {
+	// Mask is a single mask. It is ignored if Masks is set.
+	Mask string `json:"mask"`
+
+	// Masks are the lines of a .hcmask file, run in order. Each line may
+	// define its own custom charsets: "?l?d,?u,?1?1?2?2".
+	Masks []string `json:"masks,omitempty"`
+
+	// Charsets are the custom charsets ?1 to ?4 (hashcat's -1 to -4).
+	Charsets []string `json:"charsets,omitempty"`
+
+	// Increment runs the mask at every length from IncrementMin to
+	// IncrementMax; zero bounds leave the choice to hashcat.
+	Increment    bool `json:"increment,omitempty"`
+	IncrementMin int  `json:"increment_min,omitempty"`
+	IncrementMax int  `json:"increment_max,omitempty"`
+}
+
+func (c *Client) SetMaskAttack(ctx context.Context, sessionID int, attack MaskAttack) error {
+	body, _ := json.Marshal(attack)
+	endpoint := fmt.Sprintf("/mask/%d", sessionID)
+	resp, err := c.apiRequest(ctx, opControl, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "on set mask"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Actions accepted by the session execute endpoint.
+const (
+	ActionStart   = "start"
+	ActionStop    = "stop"
+	ActionPause   = "pause"
+	ActionResume  = "resume"
+	ActionRestore = "restore"
+)
+
+func (c *Client) StartJob(ctx context.Context, sessionID int) error {
+	return c.execute(ctx, sessionID, ActionStart)
+}
+
+func (c *Client) StopJob(ctx context.Context, sessionID int) error {
+	return c.execute(ctx, sessionID, ActionStop)
+}
+
+func (c *Client) PauseJob(ctx context.Context, sessionID int) error {
+	return c.execute(ctx, sessionID, ActionPause)
+}
+
+func (c *Client) ResumeJob(ctx context.Context, sessionID int) error {
+	return c.execute(ctx, sessionID, ActionResume)
+}
+
+// RestoreJob restarts a session from its last hashcat checkpoint, e.g. after
+// the server was rebooted.
+func (c *Client) RestoreJob(ctx context.Context, sessionID int) error {
+	return c.execute(ctx, sessionID, ActionRestore)
+}
+
+// execute sends one of the Action constants to a session.
+func (c *Client) execute(ctx context.Context, sessionID int, action string) error {
+	payload := map[string]string{"action": action}
+	body, _ := json.Marshal(payload)
+	endpoint := fmt.Sprintf("/sessions/%d/execute", sessionID)
+	resp, err := c.apiRequest(ctx, opControl, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "on "+action+" job"); err != nil {
+		return err
+	}
+	return nil
+}
+
+type SessionState struct {
+	State       int     `json:"state"`
+	Description string  `json:"description"`
+	Progress    float64 `json:"progress"`
+
+	// For mask attacks: the mask being run and, for mask lists and
+	// increment mode, its 1-based position among MaskCount masks.
+	CurrentMask string `json:"current_mask"`
+	MaskIndex   int    `json:"mask_index"`
+	MaskCount   int    `json:"mask_count"`
+}
+
+func (c *Client) GetState(ctx context.Context, sessionID int) (*SessionState, error) {
+	endpoint := fmt.Sprintf("/sessions/%d/state", sessionID)
+	resp, err := c.retryRequest(ctx, opPoll, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "getting state"); err != nil {
+		return nil, err
+	}
+	var state SessionState
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// ResultsKind selects which hashes DownloadResults returns.
+type ResultsKind string
+
+const (
+	ResultsCracked   ResultsKind = "cracked"   // "hash:plain" for cracked hashes
+	ResultsUncracked ResultsKind = "uncracked" // hashes still to be cracked
+	ResultsPlain     ResultsKind = "plain"     // plaintexts only, e.g. for wordlists
+	ResultsAll       ResultsKind = "all"       // every hash, potfile-style where cracked
+)
+
+// ResultsKinds lists the valid kinds in display order.
+var ResultsKinds = []ResultsKind{ResultsCracked, ResultsUncracked, ResultsPlain, ResultsAll}
+
+// ParseResultsKind parses a results kind by name. "potfile" is accepted as
+// an alias for "all".
+func ParseResultsKind(name string) (ResultsKind, error) {
+	if name == "potfile" {
+		return ResultsAll, nil
+	}
+	for _, kind := range ResultsKinds {
+		if string(kind) == name {
+			return kind, nil
+		}
+	}
+	return "", fmt.Errorf("unknown results type %q: use cracked, uncracked, plain or all", name)
+}
+
+func (c *Client) DownloadResults(ctx context.Context, sessionID int, kind ResultsKind) (string, error) {
+	payload := map[string]string{"type": string(kind)}
+	body, _ := json.Marshal(payload)
+	endpoint := fmt.Sprintf("/hashes/%d/download", sessionID)
+	resp, err := c.retryRequest(ctx, opTransfer, "POST", endpoint, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "downloading results"); err != nil {
+		return "", err
+	}
+	results, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(results), nil
+}
+
+type HashType struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+func (c *Client) GetHashTypes(ctx context.Context) ([]HashType, error) {
+	resp, err := c.retryRequest(ctx, opPoll, "GET", "/hashcat/types", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "getting hash types"); err != nil {
+		return nil, err
+	}
+	var types []HashType
+	if err := json.NewDecoder(resp.Body).Decode(&types); err != nil {
+		return nil, err
+	}
+	return types, nil
+}
+
+type FileInfo struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+func (c *Client) GetWordlists(ctx context.Context) ([]FileInfo, error) {
+	resp, err := c.retryRequest(ctx, opPoll, "GET", "/wordlists", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "getting wordlists"); err != nil {
+		return nil, err
+	}
+	var files []FileInfo
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (c *Client) GetRules(ctx context.Context) ([]FileInfo, error) {
+	resp, err := c.retryRequest(ctx, opPoll, "GET", "/rules", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "getting rules"); err != nil {
+		return nil, err
+	}
+	var files []FileInfo
+	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// UploadWordlist streams a local wordlist into the server's shared library
+// under the given file name, after which it is listed by GetWordlists.
+func (c *Client) UploadWordlist(ctx context.Context, name string, r io.Reader) error {
+	resp, err := c.uploadFile(ctx, "/wordlists/upload", name, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "uploading wordlist"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UploadSessionWordlist streams a local wordlist to a single session, e.g. a
+// per-client list that should not end up in the shared library. Select it
+// with SetWordlist and WordlistSession.
+func (c *Client) UploadSessionWordlist(ctx context.Context, sessionID int, name string, r io.Reader) error {
+	endpoint := fmt.Sprintf("/wordlists/%d/upload", sessionID)
+	resp, err := c.uploadFile(ctx, endpoint, name, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "uploading session wordlist"); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UploadRule streams a local rule file into the server's shared library
+// under the given file name, after which it is listed by GetRules.
+func (c *Client) UploadRule(ctx context.Context, name string, r io.Reader) error {
+	resp, err := c.uploadFile(ctx, "/rules/upload", name, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "uploading rule"); err != nil {
+		return err
+	}
+	return nil
+}
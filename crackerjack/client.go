@@ -0,0 +1,239 @@
+// Package crackerjack is a typed client for the CrackerJack v1 REST API. It
+// is used by the cracker-client TUI and CLI and can be imported by other
+// tooling; the API interface allows callers to substitute a mock.
+package crackerjack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"mime/multipart"
+	"net/http"
+	"time"
+)
+
+// Client is a client for interacting with the CrackerJack API.
+type Client struct {
+	client *http.Client
+	opts   Options
+}
+
+// NewClient creates a new API client.
+func NewClient(opts Options) (*Client, error) {
+	transport, err := newTransport(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		client: &http.Client{Transport: transport},
+		opts:   opts,
+	}, nil
+}
+
+// Route describes how requests reach the server: directly, or through the
+// configured or environment proxy.
+func (c *Client) Route() string {
+	req, err := http.NewRequest("GET", c.opts.URL, nil)
+	if err != nil {
+		return "unknown route"
+	}
+	transport, ok := c.client.Transport.(*http.Transport)
+	if !ok || transport.Proxy == nil {
+		return "direct connection"
+	}
+	proxyURL, err := transport.Proxy(req)
+	if err != nil {
+		return fmt.Sprintf("proxy error: %v", err)
+	}
+	if proxyURL == nil {
+		return "direct connection"
+	}
+	if c.opts.Proxy == "" {
+		return fmt.Sprintf("via proxy %s (from environment)", proxyURL.Redacted())
+	}
+	return fmt.Sprintf("via proxy %s", proxyURL.Redacted())
+}
+
+// opClass groups API calls by how long they may reasonably take, so each
+// class gets its own timeout.
+type opClass int
+
+const (
+	opPoll     opClass = iota // state polls and listings
+	opControl                 // session setup and job control
+	opTransfer                // hash and file uploads, result downloads
+)
+
+// timeout returns the per-request timeout for a class of API call.
+func (c *Client) timeout(class opClass) time.Duration {
+	timeouts := c.opts.Timeouts
+	if timeouts == nil {
+		timeouts = &Timeouts{}
+	}
+	switch class {
+	case opPoll:
+		return timeouts.Poll.Or(DefaultPollTimeout)
+	case opTransfer:
+		return timeouts.Transfer.Or(DefaultTransferTimeout)
+	default:
+		return timeouts.Control.Or(DefaultControlTimeout)
+	}
+}
+
+// apiRequest is a helper function to make requests to the API. The request is
+// bounded by the timeout of its class; the timeout keeps running until the
+// response body is closed.
+func (c *Client) apiRequest(ctx context.Context, class opClass, method, endpoint string, body io.Reader) (*http.Response, error) {
+	return c.send(ctx, class, method, endpoint, jsonHeader(), body)
+}
+
+// jsonHeader returns the request headers for a JSON body.
+func jsonHeader() http.Header {
+	return http.Header{"Content-Type": {"application/json"}}
+}
+
+// send is apiRequest with explicit request headers, for non-JSON bodies.
+func (c *Client) send(ctx context.Context, class opClass, method, endpoint string, header http.Header, body io.Reader) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout(class))
+	url := fmt.Sprintf("%s/api/v1%s", c.opts.URL, endpoint)
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("X-CrackerJack-Auth", c.opts.APIKey)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// retryRequest is like apiRequest, but retries network errors and transient
+// server errors with jittered exponential backoff. It must only be used for
+// idempotent calls.
+func (c *Client) retryRequest(ctx context.Context, class opClass, method, endpoint string, body io.Reader) (*http.Response, error) {
+	return c.retrySend(ctx, class, method, endpoint, jsonHeader(), body)
+}
+
+// retrySend is retryRequest with explicit request headers.
+func (c *Client) retrySend(ctx context.Context, class opClass, method, endpoint string, header http.Header, body io.Reader) (*http.Response, error) {
+	policy := c.opts.Retry
+	if policy == nil {
+		policy = &RetryPolicy{}
+	}
+	attempts := policy.MaxAttempts
+	if attempts <= 0 {
+		attempts = DefaultRetryAttempts
+	}
+	baseDelay := policy.BaseDelay.Or(DefaultRetryBaseDelay)
+	maxDelay := policy.MaxDelay.Or(DefaultRetryMaxDelay)
+
+	// Buffer the body so it can be replayed on every attempt.
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = io.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		var attemptBody io.Reader
+		if payload != nil {
+			attemptBody = bytes.NewReader(payload)
+		}
+		resp, err := c.send(ctx, class, method, endpoint, header, attemptBody)
+
+		var reason string
+		switch {
+		case ctx.Err() != nil:
+			return resp, err
+		case err != nil:
+			reason = err.Error()
+		case isTransientStatus(resp.StatusCode):
+			reason = resp.Status
+		default:
+			return resp, nil
+		}
+		if attempt >= attempts {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		delay := backoff(attempt, baseDelay, maxDelay)
+		if c.opts.Logf != nil {
+			c.opts.Logf("Retrying %s %s in %s (attempt %d/%d): %s", method, endpoint, delay.Round(100*time.Millisecond), attempt+1, attempts, reason)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// uploadFile streams r to endpoint as the "file" field of a multipart form,
+// so that large files are never held in memory. Uploads are not retried,
+// since the reader cannot be replayed.
+func (c *Client) uploadFile(ctx context.Context, endpoint, filename string, r io.Reader) (*http.Response, error) {
+	pr, pw := io.Pipe()
+	form := multipart.NewWriter(pw)
+	go func() {
+		part, err := form.CreateFormFile("file", filename)
+		if err == nil {
+			_, err = io.Copy(part, r)
+		}
+		if err == nil {
+			err = form.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	// Unblock the writer if the request ends before the body was consumed.
+	defer pr.Close()
+	header := http.Header{"Content-Type": {form.FormDataContentType()}}
+	return c.send(ctx, opTransfer, "POST", endpoint, header, pr)
+}
+
+// isTransientStatus reports whether an HTTP status is worth retrying.
+func isTransientStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff returns the delay before the retry following the given attempt:
+// exponential growth capped at maxDelay, with "equal jitter" so that the
+// delay is between half and all of the exponential value.
+func backoff(attempt int, baseDelay, maxDelay time.Duration) time.Duration {
+	delay := baseDelay << (attempt - 1)
+	if delay <= 0 || delay > maxDelay {
+		delay = maxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(mathrand.Int64N(int64(half)+1))
+}
+
+// cancelOnClose releases a request's context when its response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,167 @@
+package crackerjack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client for a test server running handler, with
+// retries that do not wait.
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(Options{
+		URL:    srv.URL,
+		APIKey: "secret",
+		Retry:  &RetryPolicy{BaseDelay: Duration(time.Millisecond), MaxDelay: Duration(time.Millisecond)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestBackoff(t *testing.T) {
+	base, maxDelay := 100*time.Millisecond, time.Second
+	for attempt, want := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		70: time.Second, // the shift overflows
+	} {
+		for range 20 {
+			if got := backoff(attempt, base, maxDelay); got < want/2 || got > want {
+				t.Errorf("backoff(%d) = %s, want between %s and %s", attempt, got, want/2, want)
+			}
+		}
+	}
+}
+
+func TestRetrySend(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int // the server's answers, the last one repeated
+		wantStatus   int
+		wantAttempts int
+	}{
+		{"success", []int{200}, 200, 1},
+		{"transient then success", []int{503, 429, 502, 200}, 200, 4},
+		{"attempts run out", []int{504}, 504, DefaultRetryAttempts},
+		{"client error", []int{400, 200}, 400, 1},
+		{"server error", []int{500, 200}, 500, 1},
+	}
+	for _, tt := range tests {
+		var mu sync.Mutex
+		var bodies []string
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			if r.Header.Get("X-CrackerJack-Auth") != "secret" || r.URL.Path != "/api/v1/hashes/7" {
+				t.Errorf("%s: got %s with key %q", tt.name, r.URL.Path, r.Header.Get("X-CrackerJack-Auth"))
+			}
+			mu.Lock()
+			bodies = append(bodies, string(body))
+			status := tt.statuses[min(len(bodies), len(tt.statuses))-1]
+			mu.Unlock()
+			w.WriteHeader(status)
+		})
+		var logged int
+		c.opts.Logf = func(string, ...any) { logged++ }
+
+		resp, err := c.retrySend(context.Background(), opTransfer, "POST", "/hashes/7", nil, strings.NewReader("payload"))
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.wantStatus || len(bodies) != tt.wantAttempts {
+			t.Errorf("%s: got %d after %d attempts, want %d after %d", tt.name, resp.StatusCode, len(bodies), tt.wantStatus, tt.wantAttempts)
+		}
+		if logged != tt.wantAttempts-1 {
+			t.Errorf("%s: logged %d retries, want %d", tt.name, logged, tt.wantAttempts-1)
+		}
+		for i, body := range bodies {
+			if body != "payload" {
+				t.Errorf("%s: attempt %d sent %q, want the body replayed", tt.name, i+1, body)
+			}
+		}
+	}
+}
+
+func TestRetrySendNetworkError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	c, err := NewClient(Options{
+		URL:   srv.URL,
+		Retry: &RetryPolicy{MaxAttempts: 2, BaseDelay: Duration(time.Millisecond)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged int
+	c.opts.Logf = func(string, ...any) { logged++ }
+	if _, err := c.retrySend(context.Background(), opPoll, "GET", "/sessions", nil, nil); err == nil {
+		t.Error("request to a closed server succeeded")
+	}
+	if logged != 1 {
+		t.Errorf("logged %d retries, want 1", logged)
+	}
+}
+
+func TestRetrySendCancel(t *testing.T) {
+	var attempts int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c.opts.Retry.BaseDelay, c.opts.Retry.MaxDelay = Duration(time.Hour), Duration(time.Hour)
+
+	// Cancel while the client waits before its first retry.
+	ctx, cancel := context.WithCancel(context.Background())
+	c.opts.Logf = func(string, ...any) { cancel() }
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.retrySend(ctx, opPoll, "GET", "/sessions", nil, nil)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("retrySend kept waiting after its context was canceled")
+	}
+	if attempts != 1 {
+		t.Errorf("made %d attempts, want 1", attempts)
+	}
+}
+
+func TestRetriedCallError(t *testing.T) {
+	var attempts int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintln(w, "upstream down")
+	})
+	_, err := c.GetAllSessions(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want an *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusBadGateway || apiErr.Endpoint != "/sessions" || apiErr.Message != "upstream down" {
+		t.Errorf("got %+v", apiErr)
+	}
+	if attempts != DefaultRetryAttempts {
+		t.Errorf("made %d attempts, want %d", attempts, DefaultRetryAttempts)
+	}
+}
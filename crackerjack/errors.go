@@ -0,0 +1,72 @@
+package crackerjack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// APIResponse defines the standard success/error response from the API.
+type APIResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Details string `json:"details"`
+}
+
+// APIError is returned by Client methods when the server answers with a
+// non-200 status. Message and Details carry the server's explanation (e.g.
+// an invalid mask or unknown wordlist) when the body is an APIResponse.
+type APIError struct {
+	Action     string // what the client was doing, e.g. "on set mask"
+	Endpoint   string
+	StatusCode int
+	Status     string
+	Message    string
+	Details    string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API error %s: %s", e.Action, e.Status)
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	if e.Details != "" {
+		msg += " (" + e.Details + ")"
+	}
+	return msg
+}
+
+// maxErrorBody caps how much of an error response body is read.
+const maxErrorBody = 64 << 10
+
+// checkResponse returns an *APIError if resp is not a 200 OK, decoding the
+// server's APIResponse body when there is one.
+func checkResponse(resp *http.Response, action string) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	apiErr := &APIError{
+		Action:     action,
+		Endpoint:   resp.Request.URL.Path,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+	}
+
+	if _, endpoint, found := strings.Cut(apiErr.Endpoint, "/api/v1"); found {
+		apiErr.Endpoint = endpoint
+	}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	var body APIResponse
+	if err := json.Unmarshal(data, &body); err == nil {
+		apiErr.Message, apiErr.Details = body.Message, body.Details
+	} else if text := strings.TrimSpace(string(data)); text != "" && !strings.HasPrefix(text, "<") {
+		// Plain-text error bodies are short explanations; HTML error pages are not.
+		if line, _, _ := strings.Cut(text, "\n"); len(line) <= 200 {
+			apiErr.Message = line
+		}
+	}
+	return apiErr
+}
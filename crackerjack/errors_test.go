@@ -0,0 +1,90 @@
+package crackerjack
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantMessage string
+		wantDetails string
+	}{
+		{"json", 400, `{"success":false,"message":"Invalid mask","details":"?z is not a charset"}`, "Invalid mask", "?z is not a charset"},
+		{"plain text", 404, "session not found\nat handler.go:12\n", "session not found", ""},
+		{"html", 502, "<html><body><h1>502 Bad Gateway</h1></body></html>", "", ""},
+		{"long text", 500, strings.Repeat("x", 201), "", ""},
+		{"empty", 403, "", "", ""},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{
+			StatusCode: tt.status,
+			Status:     http.StatusText(tt.status),
+			Body:       io.NopCloser(strings.NewReader(tt.body)),
+			Request:    httptest.NewRequest("POST", "https://cracker.example/api/v1/hashcat/3/mask", nil),
+		}
+		err := checkResponse(resp, "on set mask")
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Errorf("%s: got %v, want an *APIError", tt.name, err)
+			continue
+		}
+		want := APIError{
+			Action:     "on set mask",
+			Endpoint:   "/hashcat/3/mask",
+			StatusCode: tt.status,
+			Status:     http.StatusText(tt.status),
+			Message:    tt.wantMessage,
+			Details:    tt.wantDetails,
+		}
+		if *apiErr != want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, *apiErr, want)
+		}
+	}
+
+	ok := &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}
+	if err := checkResponse(ok, "on set mask"); err != nil {
+		t.Errorf("200 OK: got %v, want nil", err)
+	}
+}
+
+func TestAPIErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  APIError
+		want string
+	}{
+		{APIError{Action: "on set mask", Status: "400 Bad Request"}, "API error on set mask: 400 Bad Request"},
+		{APIError{Action: "on set mask", Status: "400 Bad Request", Message: "Invalid mask"}, "API error on set mask: 400 Bad Request: Invalid mask"},
+		{APIError{Action: "on set mask", Status: "400 Bad Request", Message: "Invalid mask", Details: "?z"}, "API error on set mask: 400 Bad Request: Invalid mask (?z)"},
+	}
+	for _, tt := range tests {
+		if got := tt.err.Error(); got != tt.want {
+			t.Errorf("Error() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestClientAPIError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		io.WriteString(w, `{"success":false,"message":"Unknown hash type","details":"99999"}`)
+	})
+	err := c.SetHashType(context.Background(), 3, "99999")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want an *APIError", err)
+	}
+	if apiErr.Endpoint != "/hashcat/3/type" || apiErr.StatusCode != http.StatusBadRequest ||
+		apiErr.Message != "Unknown hash type" || apiErr.Details != "99999" {
+		t.Errorf("got %+v", apiErr)
+	}
+}
@@ -0,0 +1,99 @@
+package crackerjack
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Options configures a Client.
+type Options struct {
+	URL    string
+	APIKey string
+
+	// Proxy is an http://, https:// or socks5:// proxy URL used to reach the
+	// server. When empty, the standard HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables apply.
+	Proxy string
+
+	TLS      *TLSConfig
+	Timeouts *Timeouts
+	Retry    *RetryPolicy
+
+	// Logf, if set, receives a line for every retried request.
+	Logf func(format string, args ...any)
+}
+
+// Defaults for retrying idempotent API calls.
+const (
+	DefaultRetryAttempts  = 4
+	DefaultRetryBaseDelay = 1 * time.Second
+	DefaultRetryMaxDelay  = 15 * time.Second
+)
+
+// RetryPolicy controls how idempotent API calls (listings, state polls,
+// result downloads and hash upload chunks) are retried after transient
+// failures. Zero values fall back to the defaults; MaxAttempts of 1 disables
+// retries.
+type RetryPolicy struct {
+	MaxAttempts int      `json:"maxAttempts,omitempty"`
+	BaseDelay   Duration `json:"baseDelay,omitempty"`
+	MaxDelay    Duration `json:"maxDelay,omitempty"`
+}
+
+// Default per-request timeouts for each class of API call.
+const (
+	DefaultPollTimeout     = 15 * time.Second
+	DefaultControlTimeout  = 30 * time.Second
+	DefaultTransferTimeout = 10 * time.Minute
+)
+
+// Timeouts overrides the per-request timeouts for each class of API call.
+// Zero values fall back to the defaults.
+type Timeouts struct {
+	Poll     Duration `json:"poll,omitempty"`
+	Control  Duration `json:"control,omitempty"`
+	Transfer Duration `json:"transfer,omitempty"`
+}
+
+// Duration is a time.Duration stored in JSON as a string such as "45s".
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Or returns d, or fallback if d is not set.
+func (d Duration) Or(fallback time.Duration) time.Duration {
+	if d <= 0 {
+		return fallback
+	}
+	return time.Duration(d)
+}
+
+// TLSConfig holds the TLS settings for self-signed and mutual-TLS
+// deployments.
+//
+// When PinSHA256 is set, the server's leaf certificate must have that SHA-256
+// fingerprint and the chain is not verified against CAs, so pinning works for
+// self-signed certificates without a CA bundle.
+type TLSConfig struct {
+	CAFile             string `json:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty"`
+	PinSHA256          string `json:"pinSHA256,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
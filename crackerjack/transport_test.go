@@ -0,0 +1,55 @@
+package crackerjack
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPinnedCertificate(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "[]")
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // rejected handshakes
+	srv.StartTLS()
+	defer srv.Close()
+	sum := sha256.Sum256(srv.Certificate().Raw)
+	var colons []string
+	for _, b := range sum {
+		colons = append(colons, fmt.Sprintf("%02X", b))
+	}
+
+	tests := []struct {
+		name    string
+		tls     *TLSConfig
+		wantErr string
+	}{
+		{"pinned", &TLSConfig{PinSHA256: fmt.Sprintf("%x", sum)}, ""},
+		{"pinned with colons", &TLSConfig{PinSHA256: strings.Join(colons, ":")}, ""},
+		{"wrong pin", &TLSConfig{PinSHA256: strings.Repeat("ab", sha256.Size)}, "does not match pinned fingerprint"},
+		{"unpinned self-signed", nil, "certificate"},
+	}
+	for _, tt := range tests {
+		c, err := NewClient(Options{URL: srv.URL, TLS: tt.tls, Retry: &RetryPolicy{MaxAttempts: 1}})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		_, err = c.GetAllSessions(context.Background())
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: got %v, want an error containing %q", tt.name, err, tt.wantErr)
+		}
+	}
+
+	if _, err := NewClient(Options{URL: srv.URL, TLS: &TLSConfig{PinSHA256: "abcd"}}); err == nil {
+		t.Error("NewClient accepted a short fingerprint")
+	}
+}
@@ -0,0 +1,196 @@
+package crackerjack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultChunkSize is the amount of hash data UploadHashesFrom sends per
+// request.
+const DefaultChunkSize = 8 << 20
+
+// HashUpload configures UploadHashesFrom.
+type HashUpload struct {
+	// ContainsUsernames marks the lines as "username:hash", as for
+	// UploadHashes.
+	ContainsUsernames bool
+
+	// Gzip compresses each chunk on the wire.
+	Gzip bool
+
+	// ChunkSize is the approximate number of bytes per chunk. Chunks are
+	// extended to the end of a line so that no hash is split. Zero means
+	// DefaultChunkSize.
+	ChunkSize int
+
+	// Offset skips this many bytes of the input that the server has
+	// already acknowledged, to resume an interrupted upload (see
+	// UploadedHashBytes).
+	Offset int64
+
+	// Progress, if set, is called with the number of input bytes the
+	// server has acknowledged after every chunk.
+	Progress func(acked int64)
+}
+
+// UploadHashesFrom streams newline-separated hashes from r in chunks, so that
+// multi-gigabyte files are never held in memory. Every chunk carries its byte
+// offset, which makes it safe to retry, so transient failures are retried
+// like idempotent calls. It returns the number of input bytes the server has
+// acknowledged; after a failure, that is the offset to resume from.
+//
+// Servers without the chunked upload endpoint get the whole input in a single
+// UploadHashes request instead, which cannot be resumed.
+func (c *Client) UploadHashesFrom(ctx context.Context, sessionID int, r io.Reader, opts HashUpload) (int64, error) {
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	acked := opts.Offset
+	if acked > 0 {
+		if err := skip(r, acked); err != nil {
+			return acked, fmt.Errorf("skipping to offset %d: %w", acked, err)
+		}
+	}
+
+	header := http.Header{"Content-Type": {"text/plain"}}
+	if opts.Gzip {
+		header.Set("Content-Encoding", "gzip")
+	}
+	br := bufio.NewReader(r)
+	for {
+		chunk, err := readChunk(br, chunkSize)
+		if err != nil {
+			return acked, err
+		}
+		_, err = br.Peek(1)
+		final := err == io.EOF
+
+		payload := chunk
+		if opts.Gzip {
+			if payload, err = gzipBytes(chunk); err != nil {
+				return acked, err
+			}
+		}
+		endpoint := fmt.Sprintf("/hashes/%d/upload/chunk?offset=%d&final=%t&contains_usernames=%t",
+			sessionID, acked, final, opts.ContainsUsernames)
+		resp, err := c.retrySend(ctx, opTransfer, "POST", endpoint, header, bytes.NewReader(payload))
+		if err != nil {
+			return acked, err
+		}
+		err = checkResponse(resp, fmt.Sprintf("uploading hashes at offset %d", acked))
+		resp.Body.Close()
+		if acked == 0 && noChunkedUpload(err) {
+			return c.uploadWhole(ctx, sessionID, chunk, br, opts)
+		}
+		if err != nil {
+			return acked, err
+		}
+
+		acked += int64(len(chunk))
+		if opts.Progress != nil {
+			opts.Progress(acked)
+		}
+		if final {
+			return acked, nil
+		}
+	}
+}
+
+// noChunkedUpload reports whether err means that the server has no chunked
+// upload endpoint, as with servers that predate it.
+func noChunkedUpload(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed)
+}
+
+// uploadWhole falls back to sending the whole input, the first chunk followed
+// by the rest of r, in a single UploadHashes request.
+func (c *Client) uploadWhole(ctx context.Context, sessionID int, first []byte, r io.Reader, opts HashUpload) (int64, error) {
+	rest, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	hashes := append(first, rest...)
+	if err := c.UploadHashes(ctx, sessionID, string(hashes), opts.ContainsUsernames); err != nil {
+		return 0, err
+	}
+	if opts.Progress != nil {
+		opts.Progress(int64(len(hashes)))
+	}
+	return int64(len(hashes)), nil
+}
+
+// UploadedHashBytes returns how many bytes of an interrupted chunked upload
+// the server has acknowledged, i.e. the HashUpload.Offset to resume from.
+func (c *Client) UploadedHashBytes(ctx context.Context, sessionID int) (int64, error) {
+	endpoint := fmt.Sprintf("/hashes/%d/upload", sessionID)
+	resp, err := c.retryRequest(ctx, opPoll, "GET", endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp, "getting upload status"); err != nil {
+		return 0, err
+	}
+	var status struct {
+		Received int64 `json:"received"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return 0, err
+	}
+	return status.Received, nil
+}
+
+// readChunk reads about size bytes from r, extended to the end of the line.
+// It returns an empty chunk at the end of the input.
+func readChunk(r *bufio.Reader, size int) ([]byte, error) {
+	chunk := make([]byte, size)
+	n, err := io.ReadFull(r, chunk)
+	chunk = chunk[:n]
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return chunk, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if chunk[n-1] != '\n' {
+		rest, err := r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		chunk = append(chunk, rest...)
+	}
+	return chunk, nil
+}
+
+// skip advances r by n bytes, seeking if r supports it.
+func skip(r io.Reader, n int64) error {
+	if s, ok := r.(io.Seeker); ok {
+		_, err := s.Seek(n, io.SeekCurrent)
+		return err
+	}
+	_, err := io.CopyN(io.Discard, r, n)
+	return err
+}
+
+// gzipBytes compresses data.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
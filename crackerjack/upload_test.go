@@ -0,0 +1,246 @@
+package crackerjack
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadChunk(t *testing.T) {
+	tests := []struct {
+		input string
+		size  int
+		want  []string
+	}{
+		{"aaaa\nbbbb\ncccc\n", 5, []string{"aaaa\n", "bbbb\n", "cccc\n"}},
+		{"aaaa\nbbbb\ncccc\n", 7, []string{"aaaa\nbbbb\n", "cccc\n"}},
+		{"aaaa\nbbbb\ncccc", 3, []string{"aaaa\n", "bbbb\n", "cccc"}},
+		{"aaaa\nbbbb\n", 100, []string{"aaaa\nbbbb\n"}},
+		{"", 4, nil},
+	}
+	for _, tt := range tests {
+		r := bufio.NewReader(strings.NewReader(tt.input))
+		var got []string
+		for {
+			chunk, err := readChunk(r, tt.size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(chunk) == 0 {
+				break
+			}
+			got = append(got, string(chunk))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("chunks of %q by %d = %q, want %q", tt.input, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestSkip(t *testing.T) {
+	readers := map[string]io.Reader{
+		"seeker":     strings.NewReader("aaaa\nbbbb\n"),
+		"non-seeker": iotest.HalfReader(strings.NewReader("aaaa\nbbbb\n")),
+	}
+	for name, r := range readers {
+		if err := skip(r, 5); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if rest, _ := io.ReadAll(r); string(rest) != "bbbb\n" {
+			t.Errorf("%s: read %q after skipping, want %q", name, rest, "bbbb\n")
+		}
+	}
+	if err := skip(iotest.HalfReader(strings.NewReader("aaaa")), 5); err == nil {
+		t.Error("skipping past the end succeeded")
+	}
+}
+
+// chunkServer records the chunks posted to the chunked upload endpoint.
+type chunkServer struct {
+	chunks  []uploadedChunk
+	failAt  int64 // offset answered with the failure status, or -1
+	failure int
+}
+
+type uploadedChunk struct {
+	offset    int64
+	final     bool
+	usernames bool
+	data      string
+}
+
+func (s *chunkServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v1/hashes/7/upload/chunk" {
+		http.NotFound(w, r)
+		return
+	}
+	query := r.URL.Query()
+	offset, _ := strconv.ParseInt(query.Get("offset"), 10, 64)
+	if offset == s.failAt {
+		w.WriteHeader(s.failure)
+		return
+	}
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body = zr
+	}
+	data, _ := io.ReadAll(body)
+	s.chunks = append(s.chunks, uploadedChunk{
+		offset:    offset,
+		final:     query.Get("final") == "true",
+		usernames: query.Get("contains_usernames") == "true",
+		data:      string(data),
+	})
+}
+
+func TestUploadHashesFrom(t *testing.T) {
+	hashes := "alice:aaaa\nbob:bbbb\ncarol:cccc\n"
+	want := []uploadedChunk{
+		{0, false, true, "alice:aaaa\nbob:bbbb\n"},
+		{20, true, true, "carol:cccc\n"},
+	}
+	for _, gzipped := range []bool{false, true} {
+		srv := &chunkServer{failAt: -1}
+		c := newTestClient(t, srv.ServeHTTP)
+		var progress []int64
+		acked, err := c.UploadHashesFrom(context.Background(), 7, strings.NewReader(hashes), HashUpload{
+			ContainsUsernames: true,
+			Gzip:              gzipped,
+			ChunkSize:         12,
+			Progress:          func(n int64) { progress = append(progress, n) },
+		})
+		if err != nil {
+			t.Fatalf("gzip %v: %v", gzipped, err)
+		}
+		if acked != int64(len(hashes)) || !slices.Equal(srv.chunks, want) {
+			t.Errorf("gzip %v: acked %d with chunks %+v, want %d with %+v", gzipped, acked, srv.chunks, len(hashes), want)
+		}
+		if !slices.Equal(progress, []int64{20, 31}) {
+			t.Errorf("gzip %v: progress %v, want [20 31]", gzipped, progress)
+		}
+	}
+}
+
+func TestUploadHashesFromResume(t *testing.T) {
+	hashes := "aaaa\nbbbb\ncccc\ndddd\n"
+	srv := &chunkServer{failAt: 10, failure: http.StatusBadRequest}
+	c := newTestClient(t, srv.ServeHTTP)
+	opts := HashUpload{ChunkSize: 5}
+
+	acked, err := c.UploadHashesFrom(context.Background(), 7, strings.NewReader(hashes), opts)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %v, want a 400 error", err)
+	}
+	if acked != 10 {
+		t.Fatalf("acked %d bytes before the failure, want 10", acked)
+	}
+
+	srv.failAt = -1
+	opts.Offset = acked
+	for name, r := range map[string]io.Reader{
+		"seeker":     strings.NewReader(hashes),
+		"non-seeker": iotest.HalfReader(strings.NewReader(hashes)),
+	} {
+		srv.chunks = nil
+		acked, err := c.UploadHashesFrom(context.Background(), 7, r, opts)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		want := []uploadedChunk{{10, false, false, "cccc\n"}, {15, true, false, "dddd\n"}}
+		if acked != int64(len(hashes)) || !slices.Equal(srv.chunks, want) {
+			t.Errorf("%s: resumed with chunks %+v, acked %d; want %+v, %d", name, srv.chunks, acked, want, len(hashes))
+		}
+	}
+}
+
+func TestUploadHashesFromRetries(t *testing.T) {
+	srv := &chunkServer{failAt: -1}
+	var attempts int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	})
+	acked, err := c.UploadHashesFrom(context.Background(), 7, strings.NewReader("aaaa\n"), HashUpload{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uploadedChunk{{0, true, false, "aaaa\n"}}
+	if acked != 5 || attempts != 2 || !slices.Equal(srv.chunks, want) {
+		t.Errorf("acked %d after %d attempts with chunks %+v, want 5 after 2 with %+v", acked, attempts, srv.chunks, want)
+	}
+}
+
+func TestUploadHashesFromFallback(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusMethodNotAllowed} {
+		var got struct {
+			Data              string `json:"data"`
+			ContainsUsernames bool   `json:"contains_usernames"`
+		}
+		var requests []string
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			if r.URL.Path != "/api/v1/hashes/7/upload" {
+				w.WriteHeader(status)
+				return
+			}
+			if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+		})
+		hashes := "aaaa\nbbbb\ncccc\n"
+		acked, err := c.UploadHashesFrom(context.Background(), 7, strings.NewReader(hashes), HashUpload{
+			ContainsUsernames: true,
+			ChunkSize:         5,
+		})
+		if err != nil {
+			t.Fatalf("%d: %v", status, err)
+		}
+		wantRequests := []string{"POST /api/v1/hashes/7/upload/chunk", "POST /api/v1/hashes/7/upload"}
+		if acked != int64(len(hashes)) || got.Data != hashes || !got.ContainsUsernames || !slices.Equal(requests, wantRequests) {
+			t.Errorf("%d: acked %d, uploaded %+v with requests %q", status, acked, got, requests)
+		}
+	}
+
+	// A resumed upload cannot fall back.
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	if _, err := c.UploadHashesFrom(context.Background(), 7, strings.NewReader("aaaa\nbbbb\n"), HashUpload{Offset: 5}); err == nil {
+		t.Error("resumed upload to a server without chunked uploads succeeded")
+	}
+}
+
+func TestUploadedHashBytes(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/api/v1/hashes/7/upload" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"received": 1048576}`)
+	})
+	got, err := c.UploadedHashBytes(context.Background(), 7)
+	if err != nil || got != 1<<20 {
+		t.Errorf("UploadedHashBytes = %d, %v; want %d", got, err, 1<<20)
+	}
+}
@@ -0,0 +1,225 @@
+// Package hashid guesses the hashcat modes of hashes from their format:
+// length, character set, "$"-prefixes and field structure. It works offline,
+// so a hash type can be suggested as soon as hashes are pasted.
+package hashid
+
+import (
+	"sort"
+	"strings"
+)
+
+// SampleLines is how many non-empty lines IdentifyText looks at.
+const SampleLines = 1000
+
+// prefixes maps the signatures of self-describing formats to their modes.
+// Longer prefixes that share a start with shorter ones come first.
+var prefixes = []struct {
+	prefix string
+	modes  []string
+}{
+	{"$krb5tgs$23$", []string{"13100"}},
+	{"$krb5tgs$17$", []string{"19600"}},
+	{"$krb5tgs$18$", []string{"19700"}},
+	{"$krb5asrep$23$", []string{"18200"}},
+	{"$krb5pa$23$", []string{"7500"}},
+	{"$krb5pa$17$", []string{"19800"}},
+	{"$krb5pa$18$", []string{"19900"}},
+	{"$DCC2$", []string{"2100"}},
+	{"$2a$", []string{"3200"}},
+	{"$2b$", []string{"3200"}},
+	{"$2x$", []string{"3200"}},
+	{"$2y$", []string{"3200"}},
+	{"$1$", []string{"500"}},
+	{"$apr1$", []string{"1600"}},
+	{"$5$", []string{"7400"}},
+	{"$6$", []string{"1800"}},
+	{"$P$", []string{"400"}},
+	{"$H$", []string{"400"}},
+	{"$8$", []string{"9200"}},
+	{"$9$", []string{"9300"}},
+	{"$ml$", []string{"7100"}},
+	{"$office$*2007*", []string{"9400"}},
+	{"$office$*2010*", []string{"9500"}},
+	{"$office$*2013*", []string{"9600"}},
+	{"$zip2$", []string{"13600"}},
+	{"$7z$", []string{"11600"}},
+	{"$keepass$", []string{"13400"}},
+	{"$bitcoin$", []string{"11300"}},
+	{"WPA*01*", []string{"22000"}},
+	{"WPA*02*", []string{"22000"}},
+	{"{SSHA512}", []string{"1711"}},
+	{"{SSHA}", []string{"111"}},
+	{"{SHA}", []string{"101"}},
+	{"sha256:", []string{"10900"}},
+	{"0x0100", []string{"132"}},
+	{"0x0200", []string{"1731"}},
+}
+
+// rawModes maps the length of an unsalted hex hash to its likely modes.
+var rawModes = map[int][]string{
+	16:  {"3000", "200"},
+	32:  {"0", "1000", "900"},
+	40:  {"100", "6000"},
+	56:  {"1300", "17300"},
+	64:  {"1400", "17400", "11700"},
+	96:  {"10800", "17500"},
+	128: {"1700", "17600", "6100"},
+}
+
+// saltedModes maps the length of a "hash:salt" hex hash to its likely modes.
+var saltedModes = map[int][]string{
+	32: {"10", "20", "1100"},
+	40: {"110", "120"},
+	64: {"1410", "1420"},
+}
+
+// The hashes of the empty password settle the NTLM versus MD5 question.
+const (
+	emptyNTLM = "31d6cfe0d16ae931b73c59d7e0c089c0"
+	emptyMD5  = "d41d8cd98f00b204e9800998ecf8427e"
+)
+
+// Identify returns the hashcat modes that hash may be, most likely first. It
+// returns nil if the format is not recognised.
+func Identify(hash string) []string {
+	hash = strings.TrimSpace(hash)
+	if hash == "" {
+		return nil
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(hash, p.prefix) {
+			return p.modes
+		}
+	}
+	if len(hash) == 41 && hash[0] == '*' && isHex(hash[1:]) {
+		return []string{"300"} // MySQL 4.1+
+	}
+
+	fields := strings.Split(hash, ":")
+	switch {
+	case len(fields) >= 6 && fields[1] == "" && isHexLen(fields[3], 16) && isHexLen(fields[4], 32) && isHex(fields[5]):
+		return []string{"5600"} // user::domain:challenge:NTProofStr:blob
+	case len(fields) >= 6 && fields[1] == "" && isHexLen(fields[3], 48) && isHexLen(fields[4], 48) && isHexLen(fields[5], 16):
+		return []string{"5500"} // user::domain:LM response:NT response:challenge
+	case len(fields) >= 4 && isDigits(fields[1]) && isHexLen(fields[2], 32) && isHexLen(fields[3], 32):
+		return []string{"1000", "3000"} // pwdump: user:rid:LM:NT:::
+	case len(fields) == 2 && isHex(fields[0]) && fields[1] != "":
+		return saltedModes[len(fields[0])]
+	case len(fields) == 1 && isHex(hash):
+		return rawHexModes(hash)
+	}
+	return nil
+}
+
+// rawHexModes orders the modes of an unsalted hex hash. NTLM is preferred
+// over MD5 for the empty NT hash and for upper-case hex, as printed by
+// Windows dumping tools.
+func rawHexModes(hash string) []string {
+	modes := rawModes[len(hash)]
+	if len(hash) != 32 {
+		return modes
+	}
+	lower := strings.ToLower(hash)
+	switch {
+	case lower == emptyMD5:
+		return []string{"0"}
+	case lower == emptyNTLM:
+		return []string{"1000"}
+	case hash != lower:
+		return []string{"1000", "0", "900"}
+	}
+	return modes
+}
+
+// Candidate is a hashcat mode that some of the identified hashes may be.
+type Candidate struct {
+	Mode  string // hashcat mode number, e.g. "1000"
+	Lines int    // sampled lines that matched the mode
+
+	rankSum int // sum of the mode's rank on each matching line
+}
+
+// IdentifyText ranks the modes of newline-separated hashes by how many of the
+// first SampleLines non-empty lines match them, and then by how likely they
+// were on each line. With usernames set, lines are "username:hash". It also
+// returns the number of lines sampled.
+func IdentifyText(text string, usernames bool) ([]Candidate, int) {
+	byMode := make(map[string]*Candidate)
+	var candidates []*Candidate
+	lines := 0
+	for rest := text; rest != "" && lines < SampleLines; {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		lines++
+		modes := identifyLine(line, usernames)
+		for rank, mode := range modes {
+			c, ok := byMode[mode]
+			if !ok {
+				c = &Candidate{Mode: mode}
+				byMode[mode] = c
+				candidates = append(candidates, c)
+			}
+			c.Lines++
+			c.rankSum += rank
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Lines != candidates[j].Lines {
+			return candidates[i].Lines > candidates[j].Lines
+		}
+		return candidates[i].rankSum < candidates[j].rankSum
+	})
+	ranked := make([]Candidate, len(candidates))
+	for i, c := range candidates {
+		ranked[i] = *c
+	}
+	return ranked, lines
+}
+
+// identifyLine identifies one input line. A "username:" prefix is only
+// stripped if the rest is recognised, since formats like pwdump and
+// NetNTLM carry the username themselves.
+func identifyLine(line string, usernames bool) []string {
+	if usernames {
+		if _, hash, ok := strings.Cut(line, ":"); ok {
+			if modes := Identify(hash); modes != nil {
+				return modes
+			}
+		}
+	}
+	return Identify(line)
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexLen(s string, n int) bool {
+	return len(s) == n && isHex(s)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
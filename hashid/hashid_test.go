@@ -0,0 +1,116 @@
+package hashid
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Example hashes, mostly from hashcat's example_hashes page.
+const (
+	md5Hash         = "8743b52063cd84097a65d1633f5c74f5"
+	ntlmHash        = "b4b9b02e6f09a9bd760f388b67351e2b"
+	sha1Hash        = "b89eaac7e61417341b710b727768294d0e6a277b"
+	sha256Hash      = "127e6fbfe24a750e72930c220a8e138275656b8e5d8f48a98c3c92df2caba935"
+	sha512Hash      = "82a9dda829eb7f8ffe9fbe49e45d47d2dad9664fbb7adf72492e3c81ebd3e29134d9bc12212bf83c6840f10e8246b9db54a4859b7ccd0123d86e5872c1e5082f"
+	md5cryptHash    = "$1$28772684$iEwNOgGugqO9.bIz5sk8k/"
+	sha512cryptHash = "$6$52450745$k5ka2p8bFuSmoVT1tzOyyuaREkkKBcCNqoDKzYiJL9RaE8yMnPgh2XzzF0NDrUhgrcLwg78xs1w5pJiypEdFX/"
+	sha256cryptHash = "$5$rounds=5000$GX7BopJZJxPc/KEK$le16UF8I2Anb.rOrn22AUPWvzUETDGefUmAV8AZkGcD"
+	bcryptHash      = "$2a$05$LhayLxezLhK1LhWvKxCyLOj0j1u.Kj0jZ0pEmm134uzrQlFvQJLF6"
+	dcc2Hash        = "$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90f"
+	netNTLMv2Hash   = "admin::N46iSNekpT:08ca45b7d7ea58ee:88dcbe4446168966a153a0064958dac6:5c7830315c7830310000000000000b45c67103d07d7b95acd12ffa11230e0000000052920b85f78d013c31cdb3b92f5d765c783030"
+	pwdumpLine      = `CORP\alice:1105:aad3b435b51404eeaad3b435b51404ee:b4b9b02e6f09a9bd760f388b67351e2b:::`
+)
+
+var (
+	tgsHash   = "$krb5tgs$23$*svc_sql$CORP.LOCAL$MSSQLSvc/db01.corp.local*$" + strings.Repeat("ab", 16) + "$" + strings.Repeat("cd", 64)
+	asrepHash = "$krb5asrep$23$bob@CORP.LOCAL:" + strings.Repeat("ab", 16) + "$" + strings.Repeat("cd", 64)
+)
+
+func TestIdentify(t *testing.T) {
+	tests := []struct {
+		hash string
+		want []string
+	}{
+		{md5Hash, []string{"0", "1000", "900"}},
+		{strings.ToUpper(ntlmHash), []string{"1000", "0", "900"}},
+		{emptyNTLM, []string{"1000"}},
+		{emptyMD5, []string{"0"}},
+		{"  " + md5Hash + "\r", []string{"0", "1000", "900"}},
+		{sha1Hash, []string{"100", "6000"}},
+		{sha256Hash, []string{"1400", "17400", "11700"}},
+		{sha512Hash, []string{"1700", "17600", "6100"}},
+		{"*" + strings.ToUpper(sha1Hash), []string{"300"}},
+		{md5Hash + ":salt", []string{"10", "20", "1100"}},
+		{md5cryptHash, []string{"500"}},
+		{"$apr1$71850310$gh9m4xcAn3MGxogwX/ztb.", []string{"1600"}},
+		{sha512cryptHash, []string{"1800"}},
+		{sha256cryptHash, []string{"7400"}},
+		{bcryptHash, []string{"3200"}},
+		{dcc2Hash, []string{"2100"}},
+		{tgsHash, []string{"13100"}},
+		{asrepHash, []string{"18200"}},
+		{"{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", []string{"101"}},
+		{netNTLMv2Hash, []string{"5600"}},
+		{"u4-netntlm::kNS:338d08f8e26de93300000000000000000000000000000000:9526fb8c23a90751cdd619b6cea564742e1e4bf33006ba41:cb8086049ec4736c", []string{"5500"}},
+		{pwdumpLine, []string{"1000", "3000"}},
+		{"", nil},
+		{"password", nil},
+		{md5Hash[:31], nil},
+		{"zz" + md5Hash[2:], nil},
+	}
+	for _, tt := range tests {
+		if got := Identify(tt.hash); !slices.Equal(got, tt.want) {
+			t.Errorf("Identify(%q) = %v, want %v", tt.hash, got, tt.want)
+		}
+	}
+}
+
+func TestIdentifyText(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		usernames bool
+		wantMode  string
+		wantLines int // lines of the top candidate
+		wantTotal int
+	}{
+		{"md5", md5Hash + "\n\n" + sha1Hash[:32] + "\n", false, "0", 2, 2},
+		{"crlf", md5Hash + "\r\n" + md5Hash + "\r\n", false, "0", 2, 2},
+		{"upper case ntlm", strings.ToUpper(ntlmHash) + "\n" + strings.ToUpper(md5Hash), false, "1000", 2, 2},
+		{"empty nt hash decides", md5Hash + "\n" + emptyNTLM, false, "1000", 2, 2},
+		{"majority", bcryptHash + "\n" + bcryptHash + "\n" + md5Hash, false, "3200", 2, 3},
+		{"usernames", "alice:" + md5cryptHash + "\nbob:" + md5cryptHash, true, "500", 2, 2},
+		{"usernames unchecked", "alice:" + md5cryptHash, false, "", 0, 1},
+		{"netntlm with usernames", netNTLMv2Hash, true, "5600", 1, 1},
+		{"pwdump with usernames", pwdumpLine, true, "1000", 1, 1},
+		{"nothing", "\n \n", false, "", 0, 0},
+	}
+	for _, tt := range tests {
+		candidates, lines := IdentifyText(tt.text, tt.usernames)
+		if lines != tt.wantTotal {
+			t.Errorf("%s: sampled %d lines, want %d", tt.name, lines, tt.wantTotal)
+		}
+		if tt.wantMode == "" {
+			if len(candidates) != 0 {
+				t.Errorf("%s: got candidates %+v, want none", tt.name, candidates)
+			}
+			continue
+		}
+		if len(candidates) == 0 {
+			t.Errorf("%s: got no candidates, want %s", tt.name, tt.wantMode)
+			continue
+		}
+		if top := candidates[0]; top.Mode != tt.wantMode || top.Lines != tt.wantLines {
+			t.Errorf("%s: top candidate is %s on %d lines, want %s on %d", tt.name, top.Mode, top.Lines, tt.wantMode, tt.wantLines)
+		}
+	}
+}
+
+func TestIdentifyTextSamples(t *testing.T) {
+	text := strings.Repeat(md5Hash+"\n", SampleLines+10)
+	candidates, lines := IdentifyText(text, false)
+	if lines != SampleLines || candidates[0].Lines != SampleLines {
+		t.Errorf("sampled %d lines (%d matching), want %d", lines, candidates[0].Lines, SampleLines)
+	}
+}
@@ -0,0 +1,151 @@
+package hashid
+
+import (
+	"bufio"
+	"hash/fnv"
+	"io"
+	"strings"
+)
+
+// NormalizeHash canonicalises a hash of the hashcat mode: surrounding
+// whitespace is trimmed and, for the unsalted and salted hex modes, whose
+// digests hashcat reads case-insensitively, the hex is lower-cased. Salts
+// and other formats are left as they are.
+func NormalizeHash(mode, hash string) string {
+	hash = strings.TrimSpace(hash)
+	if n, ok := hexLengths[mode]; ok {
+		if mode == "300" && strings.HasPrefix(hash, "*") {
+			if checkHex(hash[1:], n) == nil {
+				return "*" + strings.ToLower(hash[1:])
+			}
+			return hash
+		}
+		if checkHex(hash, n) == nil {
+			return strings.ToLower(hash)
+		}
+		return hash
+	}
+	if n, ok := saltedHexLengths[mode]; ok {
+		hex, salt, found := strings.Cut(hash, ":")
+		if found && checkHex(hex, n) == nil {
+			return strings.ToLower(hex) + ":" + salt
+		}
+	}
+	return hash
+}
+
+// NormalizeStats counts what a Normalizer did to its input.
+type NormalizeStats struct {
+	Provided int // non-empty input lines
+	Lines    int // lines written, without duplicates
+	Unique   int // distinct hashes written
+}
+
+// Normalizer is a reader over newline-separated hashes that trims the lines,
+// turns CRLFs into LFs, drops blank lines, normalises the hashes with
+// NormalizeHash and drops repeated lines. With usernames set, lines are
+// "username:hash" and only exact repeats of both are dropped, so every
+// username keeps its hash even if it shares it with other accounts. For
+// NTLM, pwdump lines are reduced to their NT hash, or to "username:hash"
+// with usernames set.
+//
+// Lines are remembered by a 128-bit digest rather than their text, so that
+// large dumps can be deduplicated without holding them in memory.
+type Normalizer struct {
+	scanner   *bufio.Scanner
+	mode      string
+	usernames bool
+	lines     map[[16]byte]struct{}
+	hashes    map[[16]byte]struct{}
+	stats     NormalizeStats
+	pending   []byte // the rest of the current output line
+	err       error
+}
+
+// NewNormalizer returns a Normalizer reading hashes of the hashcat mode
+// from r.
+func NewNormalizer(r io.Reader, mode string, usernames bool) *Normalizer {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	return &Normalizer{
+		scanner:   scanner,
+		mode:      mode,
+		usernames: usernames,
+		lines:     make(map[[16]byte]struct{}),
+		hashes:    make(map[[16]byte]struct{}),
+	}
+}
+
+func (n *Normalizer) Read(p []byte) (int, error) {
+	for len(n.pending) == 0 {
+		if n.err != nil {
+			return 0, n.err
+		}
+		if !n.scanner.Scan() {
+			n.err = n.scanner.Err()
+			if n.err == nil {
+				n.err = io.EOF
+			}
+			continue
+		}
+		if line, ok := n.next(n.scanner.Text()); ok {
+			n.pending = append(n.pending[:0], line...)
+			n.pending = append(n.pending, '\n')
+		}
+	}
+	copied := copy(p, n.pending)
+	n.pending = n.pending[copied:]
+	return copied, nil
+}
+
+// next normalises an input line and reports whether it is to be written.
+func (n *Normalizer) next(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", false
+	}
+	n.stats.Provided++
+	hash := line
+	if user, nt, ok := pwdumpHash(n.mode, line); ok {
+		hash = NormalizeHash(n.mode, nt)
+		line = hash
+		if n.usernames {
+			line = user + ":" + hash
+		}
+	} else if n.usernames {
+		if user, h, found := strings.Cut(line, ":"); found {
+			hash = NormalizeHash(n.mode, h)
+			line = user + ":" + hash
+		}
+	} else {
+		hash = NormalizeHash(n.mode, line)
+		line = hash
+	}
+
+	if !remember(n.lines, line) {
+		return "", false
+	}
+	n.stats.Lines++
+	if remember(n.hashes, hash) {
+		n.stats.Unique++
+	}
+	return line, true
+}
+
+// Stats returns the counts for the input read so far.
+func (n *Normalizer) Stats() NormalizeStats {
+	return n.stats
+}
+
+// remember adds the digest of s to seen and reports whether it was new.
+func remember(seen map[[16]byte]struct{}, s string) bool {
+	h := fnv.New128a()
+	io.WriteString(h, s)
+	var key [16]byte
+	h.Sum(key[:0])
+	if _, ok := seen[key]; ok {
+		return false
+	}
+	seen[key] = struct{}{}
+	return true
+}
@@ -0,0 +1,110 @@
+package hashid
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestNormalizeHash(t *testing.T) {
+	tests := []struct {
+		mode, hash, want string
+	}{
+		{"0", " " + strings.ToUpper(md5Hash) + "\r", md5Hash},
+		{"1000", strings.ToUpper(ntlmHash), ntlmHash},
+		{"300", "*" + strings.ToUpper(sha1Hash), "*" + sha1Hash},
+		{"10", strings.ToUpper(md5Hash) + ":SaLt", md5Hash + ":SaLt"},
+		{"0", "NOT-A-HASH", "NOT-A-HASH"}, // malformed input is left alone
+		{"0", strings.ToUpper(sha1Hash), strings.ToUpper(sha1Hash)},
+		{"1800", sha512cryptHash, sha512cryptHash}, // case-sensitive
+		{"3200", " " + bcryptHash, bcryptHash},
+		{"5600", netNTLMv2Hash, netNTLMv2Hash},
+	}
+	for _, tt := range tests {
+		if got := NormalizeHash(tt.mode, tt.hash); got != tt.want {
+			t.Errorf("NormalizeHash(%s, %q) = %q, want %q", tt.mode, tt.hash, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizer(t *testing.T) {
+	upper := strings.ToUpper(md5Hash)
+	tests := []struct {
+		name      string
+		mode      string
+		text      string
+		usernames bool
+		want      string
+		wantStats NormalizeStats
+	}{
+		{"empty", "0", "\r\n \n", false, "", NormalizeStats{}},
+		{
+			"crlf, case and duplicates", "0",
+			upper + "\r\n\r\n  " + md5Hash + "\n" + sha1Hash[:32] + "\n" + md5Hash,
+			false,
+			md5Hash + "\n" + sha1Hash[:32] + "\n",
+			NormalizeStats{Provided: 4, Lines: 2, Unique: 2},
+		},
+		{
+			"shared hashes keep their usernames", "1000",
+			"alice:" + strings.ToUpper(ntlmHash) + "\r\nbob:" + ntlmHash + "\nalice:" + ntlmHash + "\n",
+			true,
+			"alice:" + ntlmHash + "\nbob:" + ntlmHash + "\n",
+			NormalizeStats{Provided: 3, Lines: 2, Unique: 1},
+		},
+		{
+			"case-sensitive hashes", "500",
+			md5cryptHash + "\n" + strings.ToUpper(md5cryptHash) + "\n" + md5cryptHash,
+			false,
+			md5cryptHash + "\n" + strings.ToUpper(md5cryptHash) + "\n",
+			NormalizeStats{Provided: 3, Lines: 2, Unique: 2},
+		},
+		{
+			"pwdump", "1000",
+			pwdumpLine + "\n" + ntlmHash,
+			false,
+			ntlmHash + "\n",
+			NormalizeStats{Provided: 2, Lines: 1, Unique: 1},
+		},
+		{
+			"pwdump with usernames", "1000",
+			pwdumpLine + "\n" + strings.Replace(pwdumpLine, "alice", "bob", 1),
+			true,
+			`CORP\alice:` + ntlmHash + "\n" + `CORP\bob:` + ntlmHash + "\n",
+			NormalizeStats{Provided: 2, Lines: 2, Unique: 1},
+		},
+		{
+			"no final newline", "0",
+			md5Hash,
+			false,
+			md5Hash + "\n",
+			NormalizeStats{Provided: 1, Lines: 1, Unique: 1},
+		},
+	}
+	for _, tt := range tests {
+		n := NewNormalizer(strings.NewReader(tt.text), tt.mode, tt.usernames)
+		got, err := io.ReadAll(n)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		if n.Stats() != tt.wantStats {
+			t.Errorf("%s: stats %+v, want %+v", tt.name, n.Stats(), tt.wantStats)
+		}
+	}
+}
+
+func TestNormalizerSmallReads(t *testing.T) {
+	text := strings.Repeat(md5Hash+"\r\n"+sha1Hash[:32]+"\n", 3)
+	got, err := io.ReadAll(iotest.OneByteReader(NewNormalizer(strings.NewReader(text), "0", false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := md5Hash + "\n" + sha1Hash[:32] + "\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,158 @@
+package hashid
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ErrUnknownMode is returned by Validate for modes it has no rules for.
+var ErrUnknownMode = errors.New("no validation rules for this hash type")
+
+// hexLengths are the lengths of unsalted hex hashes by mode.
+var hexLengths = map[string]int{
+	"0": 32, "900": 32, "1000": 32,
+	"200": 16, "3000": 16,
+	"100": 40, "300": 40, "6000": 40,
+	"1300": 56, "17300": 56,
+	"1400": 64, "11700": 64, "17400": 64,
+	"10800": 96, "17500": 96,
+	"1700": 128, "6100": 128, "17600": 128,
+}
+
+// saltedHexLengths are the lengths of the hex part of "hash:salt" hashes by
+// mode.
+var saltedHexLengths = map[string]int{
+	"10": 32, "20": 32, "1100": 32,
+	"110": 40, "120": 40,
+	"1410": 64, "1420": 64,
+}
+
+// format is the structure of a hash that is not just hex.
+type format struct {
+	re   *regexp.Regexp
+	want string // describes the structure in error messages
+}
+
+var formats = map[string]format{
+	"400":   {regexp.MustCompile(`^\$[PH]\$[./0-9A-Za-z]{31}$`), "$P$ followed by 31 characters"},
+	"500":   {regexp.MustCompile(`^\$1\$[^$]{0,8}\$[./0-9A-Za-z]{22}$`), "$1$salt$ followed by 22 characters"},
+	"1600":  {regexp.MustCompile(`^\$apr1\$[^$]{0,8}\$[./0-9A-Za-z]{22}$`), "$apr1$salt$ followed by 22 characters"},
+	"1800":  {regexp.MustCompile(`^\$6\$(rounds=\d+\$)?[^$]{0,16}\$[./0-9A-Za-z]{86}$`), "$6$[rounds=N$]salt$ followed by 86 characters"},
+	"7400":  {regexp.MustCompile(`^\$5\$(rounds=\d+\$)?[^$]{0,16}\$[./0-9A-Za-z]{43}$`), "$5$[rounds=N$]salt$ followed by 43 characters"},
+	"3200":  {regexp.MustCompile(`^\$2[abxy]\$\d\d\$[./0-9A-Za-z]{53}$`), "$2y$cost$ followed by 53 characters"},
+	"2100":  {regexp.MustCompile(`^\$DCC2\$\d+#[^#]+#[0-9a-fA-F]{32}$`), "$DCC2$iterations#user#32 hex characters"},
+	"5500":  {regexp.MustCompile(`^[^:]+::[^:]*:[0-9a-fA-F]{48}:[0-9a-fA-F]{48}:[0-9a-fA-F]{16}$`), "user::domain:LM response:NT response:challenge"},
+	"5600":  {regexp.MustCompile(`^[^:]+::[^:]*:[0-9a-fA-F]{16}:[0-9a-fA-F]{32}:[0-9a-fA-F]+$`), "user::domain:challenge:NTProofStr:blob"},
+	"13100": {regexp.MustCompile(`^\$krb5tgs\$23\$(\*[^*]*\*\$)?[0-9a-fA-F]{32}\$[0-9a-fA-F]{64,}$`), "$krb5tgs$23$[*spn*$]checksum$data"},
+	"19600": {regexp.MustCompile(`^\$krb5tgs\$17\$[^$]+\$[^$]+\$(\*[^*]*\*\$)?[0-9a-fA-F]{24}\$[0-9a-fA-F]+$`), "$krb5tgs$17$user$realm$[*spn*$]checksum$data"},
+	"19700": {regexp.MustCompile(`^\$krb5tgs\$18\$[^$]+\$[^$]+\$(\*[^*]*\*\$)?[0-9a-fA-F]{24}\$[0-9a-fA-F]+$`), "$krb5tgs$18$user$realm$[*spn*$]checksum$data"},
+	"18200": {regexp.MustCompile(`^\$krb5asrep\$23\$[^:]+:[0-9a-fA-F]{32}\$[0-9a-fA-F]+$`), "$krb5asrep$23$user@domain:checksum$data"},
+}
+
+// CanValidate reports whether Validate has rules for mode.
+func CanValidate(mode string) bool {
+	_, hex := hexLengths[mode]
+	_, salted := saltedHexLengths[mode]
+	_, structured := formats[mode]
+	return hex || salted || structured
+}
+
+// Validate checks that hash is well-formed for the hashcat mode, for the
+// common modes. It returns ErrUnknownMode for the others.
+func Validate(mode, hash string) error {
+	if n, ok := hexLengths[mode]; ok {
+		if mode == "300" {
+			hash = strings.TrimPrefix(hash, "*")
+		}
+		return checkHex(hash, n)
+	}
+	if n, ok := saltedHexLengths[mode]; ok {
+		hex, salt, found := strings.Cut(hash, ":")
+		if !found || salt == "" {
+			return errors.New("missing the :salt after the hash")
+		}
+		return checkHex(hex, n)
+	}
+	if f, ok := formats[mode]; ok {
+		if !f.re.MatchString(hash) {
+			return fmt.Errorf("does not have the form %s", f.want)
+		}
+		return nil
+	}
+	return ErrUnknownMode
+}
+
+// pwdumpHash returns the username and NT hash of a pwdump line
+// ("user:rid:LM:NT:::", as written by secretsdump.py), which Identify
+// reports as NTLM and hashcat reads as such. ok is false for other lines and
+// for modes other than NTLM (1000).
+func pwdumpHash(mode, line string) (user, hash string, ok bool) {
+	if mode != "1000" {
+		return "", "", false
+	}
+	fields := strings.Split(line, ":")
+	if len(fields) < 4 || !isDigits(fields[1]) || !isHexLen(fields[2], 32) || !isHexLen(fields[3], 32) {
+		return "", "", false
+	}
+	return fields[0], fields[3], true
+}
+
+func checkHex(s string, n int) error {
+	if len(s) != n {
+		return fmt.Errorf("expected %d hex characters, got %d", n, len(s))
+	}
+	if !isHex(s) {
+		return errors.New("contains characters that are not hex")
+	}
+	return nil
+}
+
+// Invalid is a malformed line of input.
+type Invalid struct {
+	Line int // 1-based, counting blank lines
+	Text string
+	Err  error
+}
+
+func (i Invalid) Error() string {
+	return fmt.Sprintf("line %d: %v", i.Line, i.Err)
+}
+
+// ValidateLines checks every non-empty line of r against mode and returns
+// the malformed ones and the number of non-empty lines. With usernames set,
+// lines are "username:hash". For NTLM, pwdump lines are accepted too. It
+// returns ErrUnknownMode if the mode has no validation rules.
+func ValidateLines(mode string, r io.Reader, usernames bool) ([]Invalid, int, error) {
+	if !CanValidate(mode) {
+		return nil, 0, ErrUnknownMode
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	var invalid []Invalid
+	lines := 0
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		lines++
+		hash := line
+		if _, nt, ok := pwdumpHash(mode, line); ok {
+			hash = nt
+		} else if usernames {
+			var found bool
+			if _, hash, found = strings.Cut(line, ":"); !found {
+				invalid = append(invalid, Invalid{n, line, errors.New("missing the username: before the hash")})
+				continue
+			}
+		}
+		if err := Validate(mode, hash); err != nil {
+			invalid = append(invalid, Invalid{n, line, err})
+		}
+	}
+	return invalid, lines, scanner.Err()
+}
@@ -0,0 +1,107 @@
+package hashid
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		mode  string
+		hash  string
+		valid bool
+	}{
+		{"0", md5Hash, true},
+		{"0", strings.ToUpper(md5Hash), true},
+		{"0", md5Hash[:31], false},
+		{"0", md5Hash + "0", false},
+		{"0", "g" + md5Hash[1:], false},
+		{"0", sha1Hash, false},
+		{"1000", ntlmHash, true},
+		{"1000", pwdumpLine, false}, // ValidateLines takes the NT hash out first
+		{"100", sha1Hash, true},
+		{"1400", sha256Hash, true},
+		{"1700", sha512Hash, true},
+		{"300", "*" + sha1Hash, true},
+		{"300", sha1Hash, true},
+		{"10", md5Hash + ":salt", true},
+		{"10", md5Hash + ":", false},
+		{"10", md5Hash, false},
+		{"500", md5cryptHash, true},
+		{"500", md5cryptHash[:len(md5cryptHash)-1], false},
+		{"1600", "$apr1$71850310$gh9m4xcAn3MGxogwX/ztb.", true},
+		{"1800", sha512cryptHash, true},
+		{"1800", "$6$rounds=5000$52450745$" + sha512cryptHash[len("$6$52450745$"):], true},
+		{"1800", md5cryptHash, false},
+		{"7400", sha256cryptHash, true},
+		{"3200", bcryptHash, true},
+		{"3200", strings.Replace(bcryptHash, "$05$", "$5$", 1), false},
+		{"400", "$P$984478476IagS59wHZvyQMArzfx58u.", true},
+		{"2100", dcc2Hash, true},
+		{"2100", "$DCC2$10240#tom#e4e938d12fe5974dc42a90120bd9c90", false},
+		{"5600", netNTLMv2Hash, true},
+		{"5600", strings.Replace(netNTLMv2Hash, "08ca45b7d7ea58ee", "08ca45b7d7ea58e", 1), false},
+		{"5500", "u4-netntlm::kNS:338d08f8e26de93300000000000000000000000000000000:9526fb8c23a90751cdd619b6cea564742e1e4bf33006ba41:cb8086049ec4736c", true},
+		{"13100", tgsHash, true},
+		{"13100", "$krb5tgs$23$" + strings.Repeat("ab", 16) + "$" + strings.Repeat("cd", 64), true},
+		{"13100", "$krb5tgs$23$*svc$REALM$spn*$" + strings.Repeat("ab", 16) + "$cdcd", false},
+		{"18200", asrepHash, true},
+		{"18200", strings.Replace(asrepHash, ":", "$", 1), false},
+		{"19700", "$krb5tgs$18$svc$CORP.LOCAL$" + strings.Repeat("ab", 12) + "$" + strings.Repeat("cd", 64), true},
+	}
+	for _, tt := range tests {
+		err := Validate(tt.mode, tt.hash)
+		if tt.valid && err != nil {
+			t.Errorf("Validate(%s, %q) = %v, want nil", tt.mode, tt.hash, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("Validate(%s, %q) = nil, want an error", tt.mode, tt.hash)
+		}
+	}
+
+	if err := Validate("99999", md5Hash); !errors.Is(err, ErrUnknownMode) {
+		t.Errorf("Validate of an unknown mode = %v, want ErrUnknownMode", err)
+	}
+	if CanValidate("99999") || !CanValidate("1000") || !CanValidate("13100") || !CanValidate("10") {
+		t.Error("CanValidate does not match the validation rules")
+	}
+}
+
+func TestValidateLines(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		text      string
+		usernames bool
+		wantLines []int // line numbers of the invalid lines
+		wantTotal int
+	}{
+		{"valid", "0", md5Hash + "\r\n\n" + strings.ToUpper(md5Hash) + "\n", false, nil, 2},
+		{"blank lines count", "0", md5Hash + "\n\nnope\n" + md5Hash[:20], false, []int{3, 4}, 3},
+		{"usernames", "0", "alice:" + md5Hash + "\nbob\ncarol:" + sha1Hash, true, []int{2, 3}, 3},
+		{"pwdump as ntlm", "1000", pwdumpLine + "\n" + ntlmHash, false, nil, 2},
+		{"pwdump with usernames", "1000", pwdumpLine + " \nalice:" + ntlmHash, true, nil, 2},
+		{"pwdump as md5", "0", pwdumpLine, false, []int{1}, 1},
+		{"bad nt hash in pwdump", "1000", `alice:500:aad3b435b51404eeaad3b435b51404ee:zz::::`, true, []int{1}, 1},
+	}
+	for _, tt := range tests {
+		invalid, total, err := ValidateLines(tt.mode, strings.NewReader(tt.text), tt.usernames)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		var lines []int
+		for _, inv := range invalid {
+			lines = append(lines, inv.Line)
+		}
+		if total != tt.wantTotal || !slices.Equal(lines, tt.wantLines) {
+			t.Errorf("%s: invalid lines %v of %d, want %v of %d", tt.name, lines, total, tt.wantLines, tt.wantTotal)
+		}
+	}
+
+	if _, _, err := ValidateLines("99999", strings.NewReader(md5Hash), false); !errors.Is(err, ErrUnknownMode) {
+		t.Errorf("ValidateLines of an unknown mode = %v, want ErrUnknownMode", err)
+	}
+}
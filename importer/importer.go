@@ -0,0 +1,279 @@
+// Package importer extracts hashes and usernames from the output of common
+// dumping tools, so that dumps can be uploaded without hand-editing them.
+// Every format yields "username:hash" lines of a single hashcat mode.
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"cracker-client/hashid"
+)
+
+// Entry is a hash found in a dump.
+type Entry struct {
+	Username string
+	Hash     string
+	Mode     string // hashcat mode
+}
+
+// Format is a kind of dump.
+type Format struct {
+	Name        string
+	Description string
+
+	// parse extracts the entry of a line, or reports false for lines that
+	// carry no usable hash, such as headers and disabled accounts.
+	parse func(line string) (Entry, bool)
+}
+
+// Formats lists the supported formats.
+var Formats = []Format{
+	{"secretsdump", "secretsdump.py or pwdump NTDS output (user:rid:lm:nt:::)", parsePwdump},
+	{"shadow", "/etc/shadow (user:$6$...:...)", parseCrypt},
+	{"htpasswd", "Apache htpasswd (user:$apr1$..., {SHA}, bcrypt or crypt)", parseCrypt},
+	{"responder", "Responder logs and NTLMv1/v2 hash files (user::domain:...)", parseResponder},
+	{"kerberoast", "GetUserSPNs.py, GetNPUsers.py or Rubeus /nowrap output ($krb5tgs$, $krb5asrep$)", parseKerberos},
+}
+
+// aliases are alternative names accepted by Lookup.
+var aliases = map[string]string{
+	"pwdump":     "secretsdump",
+	"ntds":       "secretsdump",
+	"netntlm":    "responder",
+	"asreproast": "kerberoast",
+}
+
+// Names returns the names of the formats.
+func Names() []string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// Lookup returns the format with the given name or alias.
+func Lookup(name string) (*Format, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := aliases[name]; ok {
+		name = alias
+	}
+	for i := range Formats {
+		if Formats[i].Name == name {
+			return &Formats[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown hash format %q (use one of %s)", name, strings.Join(Names(), ", "))
+}
+
+// entry parses a line and checks the hash, if the mode has validation rules.
+// Entries without a username, or with one containing ':', cannot be uploaded
+// as "username:hash" and are rejected too.
+func (f *Format) entry(line string) (Entry, bool) {
+	e, ok := f.parse(line)
+	if !ok || e.Username == "" || strings.Contains(e.Username, ":") {
+		return Entry{}, false
+	}
+	if err := hashid.Validate(e.Mode, e.Hash); err != nil && !errors.Is(err, hashid.ErrUnknownMode) {
+		return Entry{}, false
+	}
+	return e, true
+}
+
+// Summary describes the contents of a dump.
+type Summary struct {
+	Modes   map[string]int // entries by hashcat mode
+	Skipped int            // non-empty lines without a usable hash
+}
+
+// Mode returns the most common hashcat mode, or "" if there are no entries.
+func (s Summary) Mode() string {
+	modes := make([]string, 0, len(s.Modes))
+	for mode := range s.Modes {
+		modes = append(modes, mode)
+	}
+	sort.Slice(modes, func(i, j int) bool {
+		if s.Modes[modes[i]] != s.Modes[modes[j]] {
+			return s.Modes[modes[i]] > s.Modes[modes[j]]
+		}
+		return modes[i] < modes[j]
+	})
+	if len(modes) == 0 {
+		return ""
+	}
+	return modes[0]
+}
+
+// Entries returns the total number of entries.
+func (s Summary) Entries() int {
+	n := 0
+	for _, count := range s.Modes {
+		n += count
+	}
+	return n
+}
+
+// Scan reads a dump and summarises the hashes in it.
+func (f *Format) Scan(r io.Reader) (Summary, error) {
+	summary := Summary{Modes: make(map[string]int)}
+	err := eachLine(r, func(line string) error {
+		if e, ok := f.entry(line); ok {
+			summary.Modes[e.Mode]++
+		} else {
+			summary.Skipped++
+		}
+		return nil
+	})
+	return summary, err
+}
+
+// Convert reads a dump and writes the entries of the given mode to w as
+// "username:hash" lines. It returns the number of lines written.
+func (f *Format) Convert(r io.Reader, w io.Writer, mode string) (int, error) {
+	bw := bufio.NewWriter(w)
+	n := 0
+	err := eachLine(r, func(line string) error {
+		e, ok := f.entry(line)
+		if !ok || e.Mode != mode {
+			return nil
+		}
+		n++
+		_, err := fmt.Fprintf(bw, "%s:%s\n", e.Username, e.Hash)
+		return err
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, bw.Flush()
+}
+
+// eachLine calls fn with every non-empty, trimmed line of r.
+func eachLine(r io.Reader, fn func(line string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := fn(line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// parsePwdump parses "DOMAIN\user:rid:lmhash:nthash:::" lines, which
+// secretsdump.py may follow with " (status=Enabled)". Machine accounts are
+// skipped, since their random passwords cannot be cracked.
+func parsePwdump(line string) (Entry, bool) {
+	fields := strings.Split(line, ":")
+	if len(fields) < 4 || !isDigits(fields[1]) || strings.HasSuffix(fields[0], "$") {
+		return Entry{}, false
+	}
+	return Entry{Username: fields[0], Hash: fields[3], Mode: "1000"}, true
+}
+
+// parseCrypt parses "user:hash[:...]" lines from /etc/shadow and htpasswd
+// files. Locked accounts keep their hash behind a "!", which is removed;
+// accounts without a password ("*", "!", "x", "") are skipped.
+func parseCrypt(line string) (Entry, bool) {
+	user, rest, ok := strings.Cut(line, ":")
+	if !ok {
+		return Entry{}, false
+	}
+	hash, _, _ := strings.Cut(rest, ":")
+	hash = strings.TrimLeft(hash, "!")
+	if user == "" || len(hash) < 13 {
+		return Entry{}, false
+	}
+	if modes := hashid.Identify(hash); len(modes) > 0 && strings.ContainsAny(hash[:1], "${") {
+		return Entry{Username: user, Hash: hash, Mode: modes[0]}, true
+	}
+	if len(hash) == 13 && isCryptChars(hash) {
+		return Entry{Username: user, Hash: hash, Mode: "1500"}, true // descrypt
+	}
+	return Entry{}, false
+}
+
+// parseResponder parses NetNTLMv1/v2 hashes, either bare as in Responder's
+// per-client hash files or after the "Hash : " label of Responder.log. The
+// username is given as DOMAIN\user.
+func parseResponder(line string) (Entry, bool) {
+	if _, hash, ok := strings.Cut(line, " : "); ok && strings.Contains(line, "Hash") {
+		line = strings.TrimSpace(hash)
+	}
+	modes := hashid.Identify(line)
+	if len(modes) == 0 || (modes[0] != "5600" && modes[0] != "5500") {
+		return Entry{}, false
+	}
+	fields := strings.Split(line, ":")
+	user := fields[0]
+	if fields[2] != "" {
+		user = fields[2] + `\` + user
+	}
+	return Entry{Username: user, Hash: line, Mode: modes[0]}, true
+}
+
+// parseKerberos parses Kerberoast and AS-REP roast hashes, which may follow
+// a label such as Rubeus' "Hash : ". The username is taken from the hash.
+func parseKerberos(line string) (Entry, bool) {
+	start := strings.Index(line, "$krb5")
+	if start < 0 {
+		return Entry{}, false
+	}
+	hash := strings.TrimSpace(line[start:])
+	modes := hashid.Identify(hash)
+	if len(modes) == 0 {
+		return Entry{}, false
+	}
+	return Entry{Username: kerberosUser(hash), Hash: hash, Mode: modes[0]}, true
+}
+
+// kerberosUser extracts the account name from a Kerberos hash:
+// "$krb5tgs$23$*user$realm$spn*$...", "$krb5tgs$18$user$realm$...",
+// "$krb5pa$18$user$realm$..." or "$krb5asrep$23$user@domain:...". It
+// returns "" for hashes without one, such as "$krb5tgs$23$checksum$data".
+func kerberosUser(hash string) string {
+	switch {
+	case strings.HasPrefix(hash, "$krb5asrep$"):
+		rest := strings.TrimPrefix(strings.TrimPrefix(hash, "$krb5asrep$"), "23$")
+		user, _, _ := strings.Cut(rest, ":")
+		return user
+	case strings.HasPrefix(hash, "$krb5tgs$23$*"):
+		user, _, _ := strings.Cut(strings.TrimPrefix(hash, "$krb5tgs$23$*"), "$")
+		return user
+	case strings.HasPrefix(hash, "$krb5tgs$17$"), strings.HasPrefix(hash, "$krb5tgs$18$"), strings.HasPrefix(hash, "$krb5pa$"):
+		if fields := strings.Split(hash, "$"); len(fields) > 4 {
+			return fields[3]
+		}
+	}
+	return ""
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isCryptChars(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '.' || c == '/' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
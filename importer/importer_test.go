@@ -0,0 +1,166 @@
+package importer
+
+import (
+	"maps"
+	"strings"
+	"testing"
+)
+
+const (
+	ntHash      = "b4b9b02e6f09a9bd760f388b67351e2b"
+	emptyLM     = "aad3b435b51404eeaad3b435b51404ee"
+	md5crypt    = "$1$28772684$iEwNOgGugqO9.bIz5sk8k/"
+	sha512crypt = "$6$52450745$k5ka2p8bFuSmoVT1tzOyyuaREkkKBcCNqoDKzYiJL9RaE8yMnPgh2XzzF0NDrUhgrcLwg78xs1w5pJiypEdFX/"
+	bcrypt      = "$2a$05$LhayLxezLhK1LhWvKxCyLOj0j1u.Kj0jZ0pEmm134uzrQlFvQJLF6"
+	netNTLMv2   = "admin::N46iSNekpT:08ca45b7d7ea58ee:88dcbe4446168966a153a0064958dac6:5c7830315c7830310000000000000b45c67103d07d7b95acd12ffa11230e0000000052920b85f78d013c31cdb3b92f5d765c783030"
+	netNTLMv1   = "u4-netntlm::kNS:338d08f8e26de93300000000000000000000000000000000:9526fb8c23a90751cdd619b6cea564742e1e4bf33006ba41:cb8086049ec4736c"
+)
+
+var (
+	checksum = strings.Repeat("ab", 16)
+	data     = strings.Repeat("cd", 64)
+	tgs23    = "$krb5tgs$23$*svc_sql$CORP.LOCAL$MSSQLSvc/db01*$" + checksum + "$" + data
+	tgs18    = "$krb5tgs$18$svc_web$CORP.LOCAL$*HTTP/web01*$" + strings.Repeat("ab", 12) + "$" + data
+	asrep    = "$krb5asrep$23$bob@CORP.LOCAL:" + checksum + "$" + data
+)
+
+func TestFormats(t *testing.T) {
+	tests := []struct {
+		format    string
+		dump      string
+		want      string // Convert's output for the summary's mode
+		wantModes map[string]int
+		wantSkip  int
+	}{
+		{
+			"secretsdump",
+			"[*] Dumping Domain Credentials (domain\\uid:rid:lmhash:nthash)\n" +
+				`CORP\alice:1105:` + emptyLM + ":" + ntHash + ":::\r\n" +
+				"Administrator:500:" + emptyLM + ":" + ntHash + "::: (status=Enabled)\n" +
+				"DC01$:1000:" + emptyLM + ":" + ntHash + ":::\n" +
+				"bad:1106:" + emptyLM + ":zz:::\n",
+			`CORP\alice:` + ntHash + "\nAdministrator:" + ntHash + "\n",
+			map[string]int{"1000": 2}, 3,
+		},
+		{
+			"shadow",
+			"root:" + sha512crypt + ":19000:0:99999:7:::\n" +
+				"daemon:*:19000:0:99999:7:::\n" +
+				"nobody:!:19000::::::\n" +
+				"locked:!" + sha512crypt + ":19000::::::\n" +
+				"old:" + md5crypt + ":19000::::::\n" +
+				"ancient:rl0uE8G0Ne5AA:19000::::::\n",
+			"root:" + sha512crypt + "\nlocked:" + sha512crypt + "\n",
+			map[string]int{"1800": 2, "500": 1, "1500": 1}, 2,
+		},
+		{
+			"htpasswd",
+			"alice:$apr1$71850310$gh9m4xcAn3MGxogwX/ztb.\n" +
+				"bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n" +
+				"carol:" + bcrypt + "\n" +
+				"dave:" + bcrypt + "\n",
+			"carol:" + bcrypt + "\ndave:" + bcrypt + "\n",
+			map[string]int{"1600": 1, "101": 1, "3200": 2}, 0,
+		},
+		{
+			"responder",
+			"[SMB] NTLMv2-SSP Client   : 10.0.0.5\n" +
+				"[SMB] NTLMv2-SSP Username : N46iSNekpT\\admin\n" +
+				"[SMB] NTLMv2-SSP Hash     : " + netNTLMv2 + "\n" +
+				netNTLMv2 + "\n" +
+				netNTLMv1 + "\n",
+			`N46iSNekpT\admin:` + netNTLMv2 + "\n" + `N46iSNekpT\admin:` + netNTLMv2 + "\n",
+			map[string]int{"5600": 2, "5500": 1}, 2,
+		},
+		{
+			"kerberoast",
+			"ServicePrincipalName  Name     MemberOf\n" +
+				tgs23 + "\n" +
+				"[*] Hash                   : " + tgs18 + "\n" +
+				"$krb5tgs$23$" + checksum + "$" + data + "\n" + // no account name
+				asrep + "\n" +
+				"$krb5pa$18$carol$CORP.LOCAL$CORP.LOCALcarol$" + data + "\n",
+			"svc_sql:" + tgs23 + "\n",
+			map[string]int{"13100": 1, "19700": 1, "18200": 1, "19900": 1}, 2,
+		},
+	}
+	for _, tt := range tests {
+		f, err := Lookup(tt.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		summary, err := f.Scan(strings.NewReader(tt.dump))
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if !maps.Equal(summary.Modes, tt.wantModes) || summary.Skipped != tt.wantSkip {
+			t.Errorf("%s: scanned %v, %d skipped; want %v, %d skipped", tt.format, summary.Modes, summary.Skipped, tt.wantModes, tt.wantSkip)
+		}
+
+		var out strings.Builder
+		mode := summary.Mode()
+		n, err := f.Convert(strings.NewReader(tt.dump), &out, mode)
+		if err != nil {
+			t.Errorf("%s: %v", tt.format, err)
+			continue
+		}
+		if out.String() != tt.want || n != summary.Modes[mode] {
+			t.Errorf("%s: converted %d lines of mode %s:\n%s\nwant:\n%s", tt.format, n, mode, out.String(), tt.want)
+		}
+	}
+}
+
+func TestKerberosUser(t *testing.T) {
+	tests := []struct {
+		hash, want string
+	}{
+		{tgs23, "svc_sql"},
+		{tgs18, "svc_web"},
+		{asrep, "bob@CORP.LOCAL"},
+		{"$krb5asrep$bob@CORP.LOCAL:" + checksum + "$" + data, "bob@CORP.LOCAL"},
+		{"$krb5pa$23$carol$CORP.LOCAL$$" + data, "carol"},
+		{"$krb5tgs$23$" + checksum + "$" + data, ""},
+		{"$krb5pa$18$", ""},
+	}
+	for _, tt := range tests {
+		if got := kerberosUser(tt.hash); got != tt.want {
+			t.Errorf("kerberosUser(%q) = %q, want %q", tt.hash, got, tt.want)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for name, want := range map[string]string{
+		"secretsdump": "secretsdump",
+		"NTDS":        "secretsdump",
+		" pwdump ":    "secretsdump",
+		"netntlm":     "responder",
+		"asreproast":  "kerberoast",
+		"shadow":      "shadow",
+	} {
+		f, err := Lookup(name)
+		if err != nil || f.Name != want {
+			t.Errorf("Lookup(%q) = %v, %v; want %s", name, f, err, want)
+		}
+	}
+	if _, err := Lookup("john"); err == nil {
+		t.Error("Lookup of an unknown format succeeded")
+	}
+}
+
+func TestSummaryMode(t *testing.T) {
+	tests := []struct {
+		modes map[string]int
+		want  string
+	}{
+		{nil, ""},
+		{map[string]int{"1800": 1, "500": 3}, "500"},
+		{map[string]int{"500": 2, "1800": 2}, "1800"}, // ties go to the lowest mode string
+	}
+	for _, tt := range tests {
+		if got := (Summary{Modes: tt.modes}).Mode(); got != tt.want {
+			t.Errorf("Mode of %v = %q, want %q", tt.modes, got, tt.want)
+		}
+	}
+}
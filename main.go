@@ -1,33 +1,94 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
+	"math/big"
+	"net/url"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
+	"cracker-client/crackerjack"
+	"cracker-client/hashid"
+	"cracker-client/importer"
+	"cracker-client/mask"
+
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
+	"golang.org/x/term"
 )
 
 // =================================================================================
 // 1. Configuration Management
 // =================================================================================
 
+// defaultProfileName is the profile created on first run and the one that
+// pre-profile config files are migrated into.
+const defaultProfileName = "default"
+
+// Profile holds the connection details for a single cracking server.
+//
+// The API key can be stored inline in APIKey, or kept out of config.json by
+// setting exactly one of the alternative sources: a file containing the key,
+// a helper command that prints it (e.g. "pass show crackerjack"), or a blob
+// encrypted with a passphrase (see encryptAPIKey).
+type Profile struct {
+	URL             string                 `json:"url"`
+	APIKey          string                 `json:"apiKey,omitempty"`
+	APIKeyFile      string                 `json:"apiKeyFile,omitempty"`
+	APIKeyCommand   string                 `json:"apiKeyCommand,omitempty"`
+	APIKeyEncrypted string                 `json:"apiKeyEncrypted,omitempty"`
+	TLS             *crackerjack.TLSConfig `json:"tls,omitempty"`
+
+	// Proxy is an http://, https:// or socks5:// proxy URL used to reach the
+	// server, e.g. an SSH dynamic forward. When empty, the standard
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables apply.
+	Proxy string `json:"proxy,omitempty"`
+
+	Timeouts *crackerjack.Timeouts    `json:"timeouts,omitempty"`
+	Retry    *crackerjack.RetryPolicy `json:"retry,omitempty"`
+
+	// FavoriteHashTypes are the hashcat modes the team uses most on this
+	// server. They are listed first in the hash type picker.
+	FavoriteHashTypes []string `json:"favoriteHashTypes,omitempty"`
+}
+
 // Config holds the application's configuration.
 type Config struct {
-	URL    string `json:"url"`
-	APIKey string `json:"apiKey"`
+	DefaultProfile string              `json:"defaultProfile"`
+	Profiles       map[string]*Profile `json:"profiles"`
+
+	// URL and APIKey are the legacy single-server fields. They are only read
+	// so that old config files can be migrated into Profiles.
+	URL    string `json:"url,omitempty"`
+	APIKey string `json:"apiKey,omitempty"`
 }
 
 var configDir string
 var configFile string
+var stateFile string
 
 // init sets up the configuration path before main() runs.
 func init() {
@@ -38,385 +99,609 @@ func init() {
 	}
 	configDir = filepath.Join(userConfigDir, "cracker-client")
 	configFile = filepath.Join(configDir, "config.json")
+	stateFile = filepath.Join(configDir, "state.json")
 }
 
 // loadConfig loads the configuration from the file, or creates it if it doesn't exist.
 func loadConfig() (*Config, error) {
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
+	config, err := readConfig()
+	if errors.Is(err, os.ErrNotExist) {
 		fmt.Println("Configuration file not found. Let's create one.")
 		return createConfig()
 	}
+	return config, err
+}
+
+// readConfig reads and migrates the configuration file without prompting.
+// The returned error wraps os.ErrNotExist if the file does not exist.
+func readConfig() (*Config, error) {
+	config, migrated, err := parseConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if migrated {
+		if err := saveConfig(config); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Migrated configuration to profile %q.\n", config.DefaultProfile)
+	}
+	return config, nil
+}
 
+// parseConfigFile reads the configuration file and migrates it in memory
+// only, reporting whether it was migrated.
+func parseConfigFile() (*Config, bool, error) {
 	data, err := os.ReadFile(configFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file: %w", err)
+		return nil, false, fmt.Errorf("failed to read config file: %w", err)
 	}
 
 	var config Config
 	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+		return nil, false, fmt.Errorf("failed to parse config file: %w", err)
 	}
-
-	return &config, nil
+	return &config, config.migrate(), nil
 }
 
-// createConfig prompts the user for configuration details and saves them.
-func createConfig() (*Config, error) {
-	var url, apiKey string
-
-	fmt.Print("Enter Server URL (e.g., http://10.0.0.5): ")
-	fmt.Scanln(&url)
-
-	fmt.Print("Enter API Key: ")
-	fmt.Scanln(&apiKey)
-
-	config := &Config{
-		URL:    strings.TrimSpace(url),
-		APIKey: strings.TrimSpace(apiKey),
+// migrate moves a single-server config into a profile named "default" and
+// reports whether anything changed.
+func (c *Config) migrate() bool {
+	if c.URL == "" && c.APIKey == "" {
+		return false
 	}
-
-	if err := saveConfig(config); err != nil {
-		return nil, err
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]*Profile)
 	}
-
-	fmt.Printf("Configuration saved to %s\n", configFile)
-	return config, nil
+	if _, exists := c.Profiles[defaultProfileName]; !exists {
+		c.Profiles[defaultProfileName] = &Profile{URL: c.URL, APIKey: c.APIKey}
+	}
+	if c.DefaultProfile == "" {
+		c.DefaultProfile = defaultProfileName
+	}
+	c.URL, c.APIKey = "", ""
+	return true
 }
 
-// saveConfig saves the configuration to the file.
-func saveConfig(config *Config) error {
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+// Profile returns the named profile, or the default profile if name is empty.
+func (c *Config) Profile(name string) (*Profile, error) {
+	if name == "" {
+		name = c.DefaultProfile
 	}
-
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+	profile, ok := c.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found in %s", name, configFile)
 	}
-
-	return os.WriteFile(configFile, data, 0600)
+	return profile, nil
 }
 
-// =================================================================================
-// 2. API Client
-// =================================================================================
-
-// APIClient is a client for interacting with the cracker API.
-type APIClient struct {
-	client *http.Client
-	config *Config
+// Validate checks that the profile has a usable server URL and API key.
+func (p *Profile) Validate() error {
+	if err := validateServerURL(p.URL); err != nil {
+		return err
+	}
+	if p.APIKey == "" {
+		return errors.New("API key is not set")
+	}
+	return nil
 }
 
-// NewAPIClient creates a new API client.
-func NewAPIClient(config *Config) *APIClient {
-	return &APIClient{
-		client: &http.Client{Timeout: 30 * time.Second},
-		config: config,
-	}
+// apiKeySource describes where the profile's API key comes from.
+func (p *Profile) apiKeySource() string {
+	switch {
+	case p.APIKey != "":
+		return redactKey(p.APIKey)
+	case p.APIKeyFile != "":
+		return fmt.Sprintf("(from file %s)", p.APIKeyFile)
+	case p.APIKeyCommand != "":
+		return fmt.Sprintf("(from command %q)", p.APIKeyCommand)
+	case p.APIKeyEncrypted != "":
+		return "(encrypted)"
+	}
+	return "(not set)"
 }
 
-// APIResponse defines the standard success/error response from the API.
-type APIResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Details string `json:"details"`
+// clearAPIKey removes the inline API key and every alternative source, so
+// that setting a new source never leaves an old plaintext key behind.
+func (p *Profile) clearAPIKey() {
+	p.APIKey, p.APIKeyFile, p.APIKeyCommand, p.APIKeyEncrypted = "", "", "", ""
 }
 
-// apiRequest is a helper function to make requests to the API.
-func (c *APIClient) apiRequest(method, endpoint string, body io.Reader) (*http.Response, error) {
-	url := fmt.Sprintf("%s/api/v1%s", c.config.URL, endpoint)
-	req, err := http.NewRequest(method, url, body)
-	if err != nil {
-		return nil, err
+// resolveAPIKey fills in APIKey from the configured secret source, if the
+// key is not already set. It may prompt for a passphrase on the terminal
+// when prompt is true.
+func (p *Profile) resolveAPIKey(prompt bool) error {
+	if p.APIKey != "" {
+		return nil
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("X-CrackerJack-Auth", c.config.APIKey)
+	switch {
+	case p.APIKeyFile != "":
+		data, err := os.ReadFile(p.APIKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to read API key file: %w", err)
+		}
+		p.APIKey = strings.TrimSpace(string(data))
+
+	case p.APIKeyCommand != "":
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.Command("cmd", "/C", p.APIKeyCommand)
+		} else {
+			cmd = exec.Command("sh", "-c", p.APIKeyCommand)
+		}
+		if prompt {
+			cmd.Stdin = os.Stdin
+			cmd.Stderr = os.Stderr
+		}
+		// Without a terminal to prompt on, such as while the TUI owns it,
+		// stdin is /dev/null and Output keeps stderr for the error.
+		out, err := cmd.Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				if msg := strings.TrimSpace(string(exitErr.Stderr)); msg != "" {
+					return fmt.Errorf("API key command failed: %w: %s", err, msg)
+				}
+			}
+			return fmt.Errorf("API key command failed: %w", err)
+		}
+		// Like git credential helpers, only the first line is the secret.
+		p.APIKey = strings.TrimSpace(strings.SplitN(string(out), "\n", 2)[0])
 
-	return c.client.Do(req)
+	case p.APIKeyEncrypted != "":
+		passphrase, err := readPassphrase(prompt, false)
+		if err != nil {
+			return err
+		}
+		key, err := decryptAPIKey(p.APIKeyEncrypted, passphrase)
+		if err != nil {
+			return err
+		}
+		p.APIKey = key
+	}
+	return nil
 }
 
-// --- API Methods ---
+// envPassphrase names the environment variable holding the passphrase for
+// encrypted API keys. cachedPassphrase remembers a passphrase typed at the
+// terminal so that switching profiles in the TUI does not need it again.
+const envPassphrase = "CRACKER_PASSPHRASE"
 
-type SessionHashcat struct {
-	Mode             int     `json:"mode"`
-	HashType         string  `json:"hashType"`
-	Wordlist         string  `json:"wordlist"`
-	Rule             string  `json:"rule"`
-	Mask             string  `json:"mask"`
-	State            int     `json:"state"`
-	StateDescription string  `json:"state_description"`
-	Progress         float64 `json:"progress"`
-	CrackedPasswords int     `json:"crackedPasswords"`
-	AllPasswords     int     `json:"allPasswords"`
-}
+var cachedPassphrase string
 
-type Session struct {
-	ID       int            `json:"id"`
-	Name     string         `json:"name"`
-	Username string         `json:"username"`
-	Hashcat  SessionHashcat `json:"hashcat"`
+// readPassphrase returns the passphrase for encrypted API keys from the
+// environment, the cache, or (when prompt is true) the terminal. With
+// confirm set, the passphrase is asked for twice.
+func readPassphrase(prompt, confirm bool) (string, error) {
+	if p := os.Getenv(envPassphrase); p != "" {
+		return p, nil
+	}
+	if cachedPassphrase != "" && !confirm {
+		return cachedPassphrase, nil
+	}
+	if !prompt {
+		return "", fmt.Errorf("API key is encrypted: set $%s to unlock it", envPassphrase)
+	}
+	passphrase, err := readSecret("Passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := readSecret("Repeat passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("passphrases do not match")
+		}
+	}
+	if passphrase == "" {
+		return "", errors.New("empty passphrase")
+	}
+	cachedPassphrase = passphrase
+	return passphrase, nil
 }
 
-type NewSessionResponse struct {
-	ID int `json:"id"`
+// readSecret prompts for a value on the terminal without echoing it.
+func readSecret(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", errors.New("cannot read a secret: stdin is not a terminal")
+	}
+	fmt.Print(prompt)
+	secret, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret: %w", err)
+	}
+	return strings.TrimSpace(string(secret)), nil
 }
 
-func (c *APIClient) CreateSession(name string) (int, error) {
-	payload := map[string]string{"name": name}
-	body, _ := json.Marshal(payload)
-	resp, err := c.apiRequest("POST", "/sessions", bytes.NewBuffer(body))
+// Parameters for encrypted API keys: PBKDF2-SHA256 derives an AES-256-GCM
+// key from the passphrase. The blob is "v1:" followed by the base64 of
+// salt, nonce and ciphertext.
+const (
+	encryptedKeyPrefix = "v1:"
+	pbkdf2Iterations   = 600000
+	pbkdf2SaltSize     = 16
+)
+
+// encryptAPIKey encrypts key with a passphrase for storage in APIKeyEncrypted.
+func encryptAPIKey(key, passphrase string) (string, error) {
+	salt := make([]byte, pbkdf2SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	gcm, err := newKeyCipher(passphrase, salt)
 	if err != nil {
-		return 0, err
+		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("API error: %s", resp.Status)
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
 	}
 
-	var sessionResp NewSessionResponse
-	if err := json.NewDecoder(resp.Body).Decode(&sessionResp); err != nil {
-		return 0, err
-	}
-	return sessionResp.ID, nil
+	blob := append(salt, nonce...)
+	blob = gcm.Seal(blob, nonce, []byte(key), nil)
+	return encryptedKeyPrefix + base64.StdEncoding.EncodeToString(blob), nil
 }
 
-func (c *APIClient) GetAllSessions() ([]Session, error) {
-	resp, err := c.apiRequest("GET", "/sessions", nil)
+// decryptAPIKey reverses encryptAPIKey.
+func decryptAPIKey(encrypted, passphrase string) (string, error) {
+	if !strings.HasPrefix(encrypted, encryptedKeyPrefix) {
+		return "", errors.New("unsupported encrypted API key format")
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, encryptedKeyPrefix))
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to decode encrypted API key: %w", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error getting sessions: %s", resp.Status)
+	if len(blob) < pbkdf2SaltSize {
+		return "", errors.New("encrypted API key is truncated")
 	}
-	var sessions []Session
-	if err := json.NewDecoder(resp.Body).Decode(&sessions); err != nil {
-		return nil, err
+	salt, rest := blob[:pbkdf2SaltSize], blob[pbkdf2SaltSize:]
+	gcm, err := newKeyCipher(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+	if len(rest) < gcm.NonceSize() {
+		return "", errors.New("encrypted API key is truncated")
+	}
+	nonce, ciphertext := rest[:gcm.NonceSize()], rest[gcm.NonceSize():]
+	key, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("failed to decrypt API key: wrong passphrase?")
 	}
-	return sessions, nil
+	return string(key), nil
 }
 
-func (c *APIClient) GetSession(id int) (*Session, error) {
-	endpoint := fmt.Sprintf("/sessions/%d", id)
-	resp, err := c.apiRequest("GET", endpoint, nil)
+// newKeyCipher derives the AES-GCM cipher for a passphrase and salt.
+func newKeyCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	derived, err := pbkdf2.Key(sha256.New, passphrase, salt, pbkdf2Iterations, 32)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error getting session %d: %s", id, resp.Status)
-	}
-	var session Session
-	if err := json.NewDecoder(resp.Body).Decode(&session); err != nil {
+	block, err := aes.NewCipher(derived)
+	if err != nil {
 		return nil, err
 	}
-	return &session, nil
+	return cipher.NewGCM(block)
 }
 
-func (c *APIClient) UploadHashes(sessionID int, hashes string) error {
-	payload := map[string]interface{}{"data": hashes, "contains_usernames": false}
-	body, _ := json.Marshal(payload)
-	endpoint := fmt.Sprintf("/hashes/%d/upload", sessionID)
-	resp, err := c.apiRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return err
+// setRetryOption updates one retry setting by its "config set" key.
+func setRetryOption(r *crackerjack.RetryPolicy, key, value string) error {
+	if key == "retry-attempts" {
+		if value == "" {
+			r.MaxAttempts = 0
+			return nil
+		}
+		attempts, err := strconv.Atoi(value)
+		if err != nil || attempts < 1 {
+			return fmt.Errorf("retry-attempts must be a positive number, got %q", value)
+		}
+		r.MaxAttempts = attempts
+		return nil
+	}
+
+	var d time.Duration
+	if value != "" {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil {
+			return fmt.Errorf("invalid duration %q: %w", value, err)
+		}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error on hash upload: %s", resp.Status)
+	if key == "retry-base-delay" {
+		r.BaseDelay = crackerjack.Duration(d)
+	} else {
+		r.MaxDelay = crackerjack.Duration(d)
 	}
 	return nil
 }
 
-func (c *APIClient) SetHashType(sessionID int, hashType string) error {
-	payload := map[string]string{"type": hashType}
-	body, _ := json.Marshal(payload)
-	endpoint := fmt.Sprintf("/hashcat/%d/type", sessionID)
-	resp, err := c.apiRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error on set hash type: %s", resp.Status)
+// setTLSOption updates one TLS setting by its "config set" key.
+func setTLSOption(c *crackerjack.TLSConfig, key, value string) error {
+	switch key {
+	case "ca-file":
+		c.CAFile = value
+	case "cert-file":
+		c.CertFile = value
+	case "key-file":
+		c.KeyFile = value
+	case "pin":
+		if value != "" {
+			if _, err := crackerjack.ParseFingerprint(value); err != nil {
+				return err
+			}
+		}
+		c.PinSHA256 = value
+	case "insecure":
+		if value == "" {
+			value = "false"
+		}
+		insecure, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("insecure must be true or false, got %q", value)
+		}
+		c.InsecureSkipVerify = insecure
 	}
 	return nil
 }
 
-func (c *APIClient) SetMode(sessionID int, mode string) error {
-	payload := map[string]string{"mode": mode}
-	body, _ := json.Marshal(payload)
-	endpoint := fmt.Sprintf("/hashcat/%d/mode", sessionID)
-	resp, err := c.apiRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error on set mode: %s", resp.Status)
+// warnings returns security warnings about the profile that must be shown
+// to the user whenever it is used.
+func (p *Profile) warnings() []string {
+	var warnings []string
+	if p.TLS != nil && p.TLS.InsecureSkipVerify && p.TLS.PinSHA256 == "" {
+		warnings = append(warnings, "WARNING: TLS certificate verification is DISABLED (insecureSkipVerify). "+
+			"Anyone on the path can impersonate the server and steal the API key and hashes.")
 	}
-	return nil
+	return warnings
 }
 
-func (c *APIClient) SetWordlist(sessionID int, wordlist string) error {
-	payload := map[string]string{"name": wordlist}
-	body, _ := json.Marshal(payload)
-	endpoint := fmt.Sprintf("/wordlists/%d/global", sessionID)
-	resp, err := c.apiRequest("POST", endpoint, bytes.NewBuffer(body))
+// validateServerURL checks that raw is an absolute http(s) URL.
+func validateServerURL(raw string) error {
+	if raw == "" {
+		return errors.New("server URL is not set")
+	}
+	u, err := url.Parse(raw)
 	if err != nil {
-		return err
+		return fmt.Errorf("invalid server URL %q: %w", raw, err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error on set wordlist: %s", resp.Status)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid server URL %q: must look like http://host[:port]", raw)
 	}
 	return nil
 }
 
-func (c *APIClient) SetRule(sessionID int, rule string) error {
-	payload := map[string]string{"name": rule}
-	body, _ := json.Marshal(payload)
-	endpoint := fmt.Sprintf("/rules/%d", sessionID)
-	resp, err := c.apiRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return err
+// redactKey hides all but the first and last few characters of an API key.
+func redactKey(key string) string {
+	if key == "" {
+		return "(not set)"
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error on set rule: %s", resp.Status)
+	if len(key) <= 8 {
+		return strings.Repeat("*", len(key))
 	}
-	return nil
+	return key[:4] + strings.Repeat("*", len(key)-8) + key[len(key)-4:]
 }
 
-func (c *APIClient) SetMask(sessionID int, mask string) error {
-	payload := map[string]string{"mask": mask}
-	body, _ := json.Marshal(payload)
-	endpoint := fmt.Sprintf("/mask/%d", sessionID)
-	resp, err := c.apiRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return err
+// ProfileNames returns the names of all profiles in sorted order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error on set mask: %s", resp.Status)
-	}
-	return nil
+	sort.Strings(names)
+	return names
 }
 
-func (c *APIClient) StartJob(sessionID int) error {
-	payload := map[string]string{"action": "start"}
-	body, _ := json.Marshal(payload)
-	endpoint := fmt.Sprintf("/sessions/%d/execute", sessionID)
-	resp, err := c.apiRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return err
+// createConfig prompts the user for configuration details and saves them.
+func createConfig() (*Config, error) {
+	config := &Config{
+		DefaultProfile: defaultProfileName,
+		Profiles:       make(map[string]*Profile),
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("API error on start job: %s", resp.Status)
+	if _, err := createProfile(config, defaultProfileName); err != nil {
+		return nil, err
 	}
-	return nil
+	return config, nil
 }
 
-type SessionState struct {
-	State       int     `json:"state"`
-	Description string  `json:"description"`
-	Progress    float64 `json:"progress"`
-}
+// createProfile prompts the user for a new profile's details, adds it to the
+// configuration and saves it.
+func createProfile(config *Config, name string) (*Profile, error) {
+	var url, apiKey string
 
-func (c *APIClient) GetState(sessionID int) (*SessionState, error) {
-	endpoint := fmt.Sprintf("/sessions/%d/state", sessionID)
-	resp, err := c.apiRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, err
+	fmt.Print("Enter Server URL (e.g., http://10.0.0.5): ")
+	fmt.Scanln(&url)
+
+	fmt.Print("Enter API Key: ")
+	fmt.Scanln(&apiKey)
+
+	profile := &Profile{
+		URL:    strings.TrimSpace(url),
+		APIKey: strings.TrimSpace(apiKey),
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error getting state: %s", resp.Status)
+	if config.Profiles == nil {
+		config.Profiles = make(map[string]*Profile)
 	}
-	var state SessionState
-	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+	config.Profiles[name] = profile
+
+	if err := saveConfig(config); err != nil {
 		return nil, err
 	}
-	return &state, nil
+
+	fmt.Printf("Profile %q saved to %s\n", name, configFile)
+	return profile, nil
 }
 
-func (c *APIClient) DownloadResults(sessionID int) (string, error) {
-	payload := map[string]string{"type": "cracked"}
-	body, _ := json.Marshal(payload)
-	endpoint := fmt.Sprintf("/hashes/%d/download", sessionID)
-	resp, err := c.apiRequest("POST", endpoint, bytes.NewBuffer(body))
-	if err != nil {
-		return "", err
+// Environment variables that override config.json, so the client can run
+// headless in CI runners and containers.
+const (
+	envProfile = "CRACKER_PROFILE"
+	envURL     = "CRACKER_URL"
+	envAPIKey  = "CRACKER_API_KEY"
+)
+
+// resolveProfile layers the configuration: command-line flags override
+// environment variables, which override the selected profile in config.json.
+// When the URL and API key both come from flags or the environment,
+// config.json is optional and the user is never prompted.
+func resolveProfile(args *cliArgs) (*Config, string, *Profile, error) {
+	name := firstNonEmpty(args.profile, os.Getenv(envProfile))
+	override := Profile{
+		URL:    firstNonEmpty(args.url, os.Getenv(envURL)),
+		APIKey: firstNonEmpty(args.apiKey, os.Getenv(envAPIKey)),
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error downloading results: %s", resp.Status)
+	headless := override.URL != "" && override.APIKey != ""
+
+	var config *Config
+	var err error
+	if headless {
+		// Headless runs may have a read-only config.json, so a legacy
+		// config is migrated in memory but not saved.
+		config, _, err = parseConfigFile()
+		if errors.Is(err, os.ErrNotExist) {
+			config, err = &Config{}, nil
+		}
+	} else {
+		config, err = loadConfig()
 	}
-	results, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return nil, "", nil, err
 	}
-	return string(results), nil
-}
 
-type HashType struct {
-	Type string `json:"type"`
-	Name string `json:"name"`
-}
+	if name == "" {
+		name = config.DefaultProfile
+	}
+	if name == "" {
+		name = defaultProfileName
+	}
 
-func (c *APIClient) GetHashTypes() ([]HashType, error) {
-	resp, err := c.apiRequest("GET", "/hashcat/types", nil)
+	profile, err := config.Profile(name)
 	if err != nil {
-		return nil, err
+		if headless {
+			profile = &Profile{}
+		} else {
+			fmt.Printf("Profile %q not found. Let's create it.\n", name)
+			if profile, err = createProfile(config, name); err != nil {
+				return nil, "", nil, fmt.Errorf("failed to create profile: %w", err)
+			}
+		}
+	}
+
+	effective := *profile
+	if override.URL != "" {
+		effective.URL = strings.TrimSuffix(override.URL, "/")
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error getting hash types: %s", resp.Status)
+	if override.APIKey != "" {
+		effective.APIKey = override.APIKey
 	}
-	var types []HashType
-	if err := json.NewDecoder(resp.Body).Decode(&types); err != nil {
-		return nil, err
+	if err := effective.resolveAPIKey(true); err != nil {
+		return nil, "", nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	if err := effective.Validate(); err != nil {
+		return nil, "", nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	return config.withProfile(name, &effective), name, &effective, nil
+}
+
+// withProfile returns a copy of the config in which name maps to profile.
+// It hands the effective, overridden profile to the TUI without touching
+// what is saved on disk.
+func (c *Config) withProfile(name string, profile *Profile) *Config {
+	copied := *c
+	copied.Profiles = make(map[string]*Profile, len(c.Profiles)+1)
+	for n, p := range c.Profiles {
+		copied.Profiles[n] = p
 	}
-	return types, nil
+	copied.Profiles[name] = profile
+	if copied.DefaultProfile == "" {
+		copied.DefaultProfile = name
+	}
+	return &copied
 }
 
-type FileInfo struct {
-	Name string `json:"name"`
-	Size int64  `json:"size"`
+// firstNonEmpty returns the first of values that is not empty.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
 
-func (c *APIClient) GetWordlists() ([]FileInfo, error) {
-	resp, err := c.apiRequest("GET", "/wordlists", nil)
-	if err != nil {
-		return nil, err
+// saveConfig saves the configuration to the file.
+func saveConfig(config *Config) error {
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error getting wordlists: %s", resp.Status)
+
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
 	}
-	var files []FileInfo
-	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
-		return nil, err
+
+	return os.WriteFile(configFile, data, 0600)
+}
+
+// State holds what the client remembers between runs. It is kept apart from
+// config.json, so that saving it never rewrites the configuration.
+type State struct {
+	RecentHashTypes []string `json:"recentHashTypes,omitempty"` // most recent first
+}
+
+// maxRecentHashTypes is how many recently used hash types are remembered.
+const maxRecentHashTypes = 5
+
+// loadState reads the state file. A missing or unreadable file yields an
+// empty state.
+func loadState() *State {
+	var state State
+	if data, err := os.ReadFile(stateFile); err == nil {
+		json.Unmarshal(data, &state)
 	}
-	return files, nil
+	return &state
 }
 
-func (c *APIClient) GetRules() ([]FileInfo, error) {
-	resp, err := c.apiRequest("GET", "/rules", nil)
-	if err != nil {
-		return nil, err
+// rememberHashType moves mode to the front of the recently used hash types
+// and saves the state. It returns the updated list.
+func rememberHashType(mode string) ([]string, error) {
+	state := loadState()
+	recent := []string{mode}
+	for _, m := range state.RecentHashTypes {
+		if m != mode && len(recent) < maxRecentHashTypes {
+			recent = append(recent, m)
+		}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error getting rules: %s", resp.Status)
+	state.RecentHashTypes = recent
+
+	if err := os.MkdirAll(configDir, 0755); err != nil {
+		return recent, fmt.Errorf("failed to create config directory: %w", err)
 	}
-	var files []FileInfo
-	if err := json.NewDecoder(resp.Body).Decode(&files); err != nil {
-		return nil, err
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return recent, fmt.Errorf("failed to marshal state: %w", err)
 	}
-	return files, nil
+	return recent, os.WriteFile(stateFile, data, 0600)
+}
+
+// =================================================================================
+// 2. API Client
+// =================================================================================
+
+// The API client itself lives in the crackerjack package.
+
+// newClient creates an API client for a server profile. logf, if not nil,
+// receives a line for every retried request.
+func newClient(profile *Profile, logf func(format string, args ...any)) (*crackerjack.Client, error) {
+	return crackerjack.NewClient(crackerjack.Options{
+		URL:      profile.URL,
+		APIKey:   profile.APIKey,
+		Proxy:    profile.Proxy,
+		TLS:      profile.TLS,
+		Timeouts: profile.Timeouts,
+		Retry:    profile.Retry,
+		Logf:     logf,
+	})
 }
 
 // =================================================================================
@@ -426,21 +711,86 @@ func (c *APIClient) GetRules() ([]FileInfo, error) {
 // TUIApp holds the state and components for the TUI.
 type TUIApp struct {
 	app             *tview.Application
-	client          *APIClient
+	ctx             context.Context
+	cancel          context.CancelFunc
+	client          crackerjack.API
+	config          *Config
+	profileName     string
 	logView         *tview.TextView
 	sessionID       int
+	usernames       bool // the current session's hashes include usernames
 	isJobRunning    bool
-	sessions        []Session
+	sessions        []crackerjack.Session
+	updateEstimate  func() // refreshes the mask estimate in the form
+	maskFile        maskFileCache
+	updateDetection func() // re-identifies the pasted hashes
+	detected        []hashTypeMatch
+	detectedLines   int      // lines of pasted hashes that were sampled
+	recentHashTypes []string // most recent first, see rememberHashType
 	hashTypeOptions []string
 	wordlistOptions []string
 	ruleOptions     []string
+
+	// sessionWordlists are the wordlists of the current session, offered
+	// after the shared ones in the Wordlist dropdown.
+	sessionWordlists []sessionWordlist
+}
+
+// sessionWordlist is a wordlist uploaded, or to be uploaded, to a session
+// rather than the shared library.
+type sessionWordlist struct {
+	name string
+	file string // the local file while it still has to be uploaded
 }
 
-func NewTUIApp(client *APIClient) *TUIApp {
+// sessionWordlistSuffix marks session wordlists in the Wordlist dropdown.
+const sessionWordlistSuffix = " (session)"
+
+func NewTUIApp(config *Config, profileName string) *TUIApp {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &TUIApp{
-		app:    tview.NewApplication(),
-		client: client,
+		app:             tview.NewApplication(),
+		ctx:             ctx,
+		cancel:          cancel,
+		config:          config,
+		profileName:     profileName,
+		recentHashTypes: loadState().RecentHashTypes,
+	}
+}
+
+// connect creates the API client for a resolved profile. Retry notices from
+// the client go to the log view.
+func (t *TUIApp) connect(profile *Profile) error {
+	client, err := newClient(profile, t.logf)
+	if err != nil {
+		return err
 	}
+	t.client = client
+	return nil
+}
+
+// logError logs err in red. The message is escaped, since server-supplied
+// explanations (e.g. invalid masks) may contain square brackets.
+func (t *TUIApp) logError(prefix string, err error) {
+	t.log(fmt.Sprintf("[red]%s: %s", prefix, tview.Escape(err.Error())))
+}
+
+// logf logs from any goroutine, including the UI goroutine itself, by
+// queueing the write from a new goroutine: QueueUpdateDraw blocks until the
+// UI goroutine runs the update, so calling it there directly would hang.
+// Like logError, it escapes the message, which may carry server-supplied
+// error text.
+func (t *TUIApp) logf(format string, args ...any) {
+	msg := "[yellow]" + tview.Escape(fmt.Sprintf(format, args...))
+	go t.app.QueueUpdateDraw(func() {
+		t.log(msg)
+	})
+}
+
+// quit aborts any in-flight API calls and stops the application.
+func (t *TUIApp) quit() {
+	t.cancel()
+	t.app.Stop()
 }
 
 func (t *TUIApp) log(msg string) {
@@ -465,7 +815,14 @@ func (t *TUIApp) Run() {
 	form.SetBorder(true).SetTitle("Job Configuration")
 
 	resultsTable := tview.NewTable().SetBorders(true)
-	resultsTable.SetBorder(true).SetTitle("Results")
+	resultsTable.SetBorder(true).SetTitle("Results (e: Export)")
+	resultsTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Rune() == 'e' {
+			t.exportResults(pages)
+			return nil
+		}
+		return event
+	})
 	resultsTable.SetCell(0, 0, tview.NewTableCell("Hash").SetSelectable(false).SetTextColor(tview.Styles.SecondaryTextColor))
 	resultsTable.SetCell(0, 1, tview.NewTableCell("Plaintext").SetSelectable(false).SetTextColor(tview.Styles.SecondaryTextColor))
 
@@ -473,40 +830,207 @@ func (t *TUIApp) Run() {
 	progressGauge.SetBorder(true).SetTitle("Progress")
 
 	statusTable := tview.NewTable().SetBorders(true).SetSelectable(true, false)
-	statusTable.SetBorder(true).SetTitle("Sessions Status")
+	statusTable.SetBorder(true).SetTitle("Sessions Status (s: Stop, p: Pause, r: Resume, o: Restore, n: Rename, d: Delete)")
+	statusTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 's':
+			t.sessionAction(statusTable, crackerjack.ActionStop)
+		case 'p':
+			t.sessionAction(statusTable, crackerjack.ActionPause)
+		case 'r':
+			t.sessionAction(statusTable, crackerjack.ActionResume)
+		case 'o':
+			t.sessionAction(statusTable, crackerjack.ActionRestore)
+		case 'n':
+			t.renameSession(pages, statusTable)
+		case 'd':
+			t.confirmDelete(pages, statusTable)
+		default:
+			return event
+		}
+		return nil
+	})
 
 	// --- Form Fields ---
+	profileNames := t.config.ProfileNames()
+	profileDropdown := tview.NewDropDown().SetLabel("Profile").SetOptions(profileNames, nil)
+	for i, name := range profileNames {
+		if name == t.profileName {
+			profileDropdown.SetCurrentOption(i)
+			break
+		}
+	}
 	sessionDropdown := tview.NewDropDown().SetLabel("Load Session")
 	sessionNameInput := tview.NewInputField().SetLabel("Session Name").SetFieldWidth(30)
 	hashesInput := tview.NewTextArea().SetLabel("Hashes").SetWordWrap(true)
-	hashTypeDropdown := tview.NewDropDown().SetLabel("Hash Type")
-	attackModeDropdown := tview.NewDropDown().SetLabel("Attack Mode").SetOptions([]string{"wordlist", "mask"}, nil)
+	usernamesCheckbox := tview.NewCheckbox().SetLabel("Hashes Contain Usernames")
+	detectedView := tview.NewTextView().SetLabel("Detected").SetDynamicColors(true).SetSize(2, 0)
+	hashTypeInput := tview.NewInputField().SetLabel("Hash Type").SetFieldWidth(40).SetPlaceholder("type to search, e.g. kerb or 13100")
+	attackModeDropdown := tview.NewDropDown().SetLabel("Attack Mode").SetOptions(crackerjack.Modes, nil)
 	wordlistDropdown := tview.NewDropDown().SetLabel("Wordlist")
+	leftWordlistDropdown := tview.NewDropDown().SetLabel("Left Wordlist")
+	leftRuleInput := tview.NewInputField().SetLabel("Left Rule").SetFieldWidth(20)
+	rightWordlistDropdown := tview.NewDropDown().SetLabel("Right Wordlist")
+	rightRuleInput := tview.NewInputField().SetLabel("Right Rule").SetFieldWidth(20)
+	wordlistDropdowns := []*tview.DropDown{wordlistDropdown, leftWordlistDropdown, rightWordlistDropdown}
 	rulesDropdown := tview.NewDropDown().SetLabel("Rules")
 	maskInput := tview.NewInputField().SetLabel("Mask").SetFieldWidth(30)
+	maskFileInput := tview.NewInputField().SetLabel("Mask File").SetFieldWidth(30).SetPlaceholder("F7: pick a .hcmask file")
+	charsetsInput := tview.NewInputField().SetLabel("Charsets").SetFieldWidth(30).SetPlaceholder("?1,?2,?3,?4 e.g. ?l?d,?u")
+	incrementInput := tview.NewInputField().SetLabel("Increment").SetFieldWidth(10).SetPlaceholder("min-max")
+	estimateView := tview.NewTextView().SetLabel("Estimate").SetDynamicColors(true).SetSize(2, 0)
+	maskFields := []tview.FormItem{maskInput, maskFileInput, charsetsInput, incrementInput, estimateView}
+
+	// The pasted hashes are identified as they change. The most likely hash
+	// type is selected whenever it changes, so a manual choice sticks until
+	// different hashes are pasted.
+	var detectedTop string
+	t.updateDetection = func() {
+		t.detected, t.detectedLines = detectHashTypes(hashesInput.GetText(), usernamesCheckbox.IsChecked(), t.hashTypeOptions)
+		switch {
+		case t.detectedLines == 0:
+			detectedView.SetText("")
+		case len(t.detected) == 0:
+			detectedView.SetText("[yellow]Unknown hash format")
+		default:
+			names := make([]string, 0, 3)
+			for _, match := range t.detected[:min(3, len(t.detected))] {
+				names = append(names, tview.Escape(match.option))
+			}
+			detectedView.SetText(strings.Join(names, ", "))
+		}
+		top := ""
+		if len(t.detected) > 0 {
+			top = t.detected[0].option
+			if top != detectedTop || hashTypeInput.GetText() == "" {
+				hashTypeInput.SetText(top)
+			}
+		}
+		detectedTop = top
+	}
+	hashesInput.SetChangedFunc(func() {
+		t.updateDetection()
+	})
+	usernamesCheckbox.SetChangedFunc(func(bool) {
+		t.updateDetection()
+	})
 
-	// REMOVED auto-detection logic
-	// hashesInput.SetChangedFunc(...)
+	// The Hash Type field filters the server's hash types as you type, with
+	// favourite and recently used types first. Down opens the full list.
+	var hashTypeEntries []string
+	hashTypeInput.SetAutocompleteFunc(func(text string) []string {
+		if _, ok := hashTypeOf(text); ok && slices.Contains(t.hashTypeOptions, text) {
+			return nil
+		}
+		pinned := t.pinnedHashTypes()
+		hashTypeEntries = searchHashTypes(text, t.hashTypeOptions, pinned)
+		labels := make([]string, len(hashTypeEntries))
+		for i, option := range hashTypeEntries {
+			mode, _ := hashTypeOf(option)
+			labels[i] = tview.Escape(option)
+			if slices.Contains(pinned, mode) {
+				labels[i] = "[yellow]*[-] " + labels[i]
+			}
+		}
+		return labels
+	})
+	hashTypeInput.SetAutocompletedFunc(func(_ string, index, source int) bool {
+		if source == tview.AutocompletedNavigate {
+			return false
+		}
+		hashTypeInput.SetText(hashTypeEntries[index])
+		return true
+	})
+	hashTypeInput.SetChangedFunc(func(string) {
+		t.updateEstimate()
+	})
 
-	form.AddFormItem(sessionDropdown).
+	form.AddFormItem(profileDropdown).
+		AddFormItem(sessionDropdown).
 		AddFormItem(sessionNameInput).
 		AddFormItem(hashesInput).
-		AddFormItem(hashTypeDropdown).
-		AddFormItem(attackModeDropdown).
-		AddFormItem(wordlistDropdown).
-		AddFormItem(rulesDropdown).
-		AddFormItem(maskInput)
-
-	go t.loadInitialData(sessionDropdown, hashTypeDropdown, wordlistDropdown, rulesDropdown, form, resultsTable)
-
-	// REMOVED "Detect Type" button and reordered
+		AddFormItem(usernamesCheckbox).
+		AddFormItem(detectedView).
+		AddFormItem(hashTypeInput).
+		AddFormItem(attackModeDropdown)
+
+	// Only the fields used by the selected attack mode are shown, after the
+	// Attack Mode dropdown.
+	modeFields := map[string][]tview.FormItem{
+		crackerjack.ModeWordlist:           {wordlistDropdown, rulesDropdown},
+		crackerjack.ModeCombinator:         {leftWordlistDropdown, leftRuleInput, rightWordlistDropdown, rightRuleInput},
+		crackerjack.ModeMask:               maskFields,
+		crackerjack.ModeHybridWordlistMask: append([]tview.FormItem{wordlistDropdown}, maskFields...),
+		crackerjack.ModeHybridMaskWordlist: append(slices.Clone(maskFields), wordlistDropdown),
+	}
+	t.updateEstimate = func() {
+		estimate, err := t.estimateMask(form)
+		switch {
+		case err != nil:
+			estimateView.SetText("[red]" + tview.Escape(err.Error()))
+		case estimate == nil:
+			estimateView.SetText("")
+		case estimate.absurd():
+			estimateView.SetText("[red]" + estimate.String())
+		default:
+			estimateView.SetText(estimate.String())
+		}
+	}
+	for _, input := range []*tview.InputField{maskInput, charsetsInput, incrementInput} {
+		input.SetChangedFunc(func(string) {
+			t.updateEstimate()
+		})
+	}
+	maskFileInput.SetChangedFunc(func(string) {
+		t.maskFile = maskFileCache{}
+		t.updateEstimate()
+	})
+	attackModeDropdown.SetSelectedFunc(func(text string, _ int) {
+		showModeFields(form, modeFields[text])
+		t.updateEstimate()
+	})
+	attackModeDropdown.SetCurrentOption(0)
+
+	go t.loadInitialData(sessionDropdown, hashTypeInput, wordlistDropdowns, rulesDropdown, form, resultsTable)
+
+	// keepProfile puts the profile dropdown back on the current profile.
+	keepProfile := func() {
+		if i := slices.Index(profileNames, t.profileName); i >= 0 {
+			profileDropdown.SetCurrentOption(i)
+		}
+	}
+	profileDropdown.SetSelectedFunc(func(text string, index int) {
+		if text == t.profileName {
+			return
+		}
+		if t.isJobRunning {
+			t.log("[yellow]Cannot switch profiles while a job is running.")
+			keepProfile()
+			return
+		}
+		if err := t.switchProfile(text); err != nil {
+			t.logError("Error switching profile", err)
+			keepProfile()
+			return
+		}
+		form.GetFormItemByLabel("Session Name").(*tview.InputField).SetText("")
+		t.displayResults(resultsTable, "", false)
+		go t.loadInitialData(sessionDropdown, hashTypeInput, wordlistDropdowns, rulesDropdown, form, resultsTable)
+	})
+
 	form.AddButton("Start / Update Job", func() {
-		t.startJob(form, progressGauge, resultsTable)
+		t.confirmStart(pages, form, func() {
+			t.confirmHashes(pages, form, func() {
+				t.startJob(form, progressGauge, resultsTable)
+			})
+		})
+	}).AddButton("Detect Type", func() {
+		t.pickDetectedType(pages, hashTypeInput)
 	}).AddButton("Refresh Status", func() {
 		t.refreshStatus(statusTable)
 		pages.SwitchToPage("status")
 	}).AddButton("Quit", func() {
-		t.app.Stop()
+		t.quit()
 	})
 
 	// --- Main Layouts ---
@@ -523,18 +1047,47 @@ func (t *TUIApp) Run() {
 	mainViewGrid.AddItem(rightPanel, 0, 1, 1, 1, 0, 0, false)
 	mainViewGrid.AddItem(progressGauge, 1, 0, 1, 2, 0, 0, false)
 
+	statusButtons := tview.NewForm().SetHorizontal(true).
+		AddButton("Stop", func() { t.sessionAction(statusTable, crackerjack.ActionStop) }).
+		AddButton("Pause", func() { t.sessionAction(statusTable, crackerjack.ActionPause) }).
+		AddButton("Resume", func() { t.sessionAction(statusTable, crackerjack.ActionResume) }).
+		AddButton("Restore", func() { t.sessionAction(statusTable, crackerjack.ActionRestore) }).
+		AddButton("Rename", func() { t.renameSession(pages, statusTable) }).
+		AddButton("Delete", func() { t.confirmDelete(pages, statusTable) }).
+		AddButton("Back", func() { pages.SwitchToPage("main") })
+	statusPage := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(statusTable, 0, 1, true).
+		AddItem(statusButtons, 3, 0, false)
+
 	pages.AddPage("main", mainViewGrid, true, true)
-	pages.AddPage("status", statusTable, true, false)
+	pages.AddPage("status", statusPage, true, false)
 
 	// --- Hotkeys ---
 	t.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
 		switch event.Key() {
 		case tcell.KeyCtrlQ:
-			t.app.Stop()
+			t.quit()
 			return nil
 		case tcell.KeyF2:
 			pages.SwitchToPage("main")
 			return nil
+		case tcell.KeyF4:
+			t.exportResults(pages)
+			return nil
+		case tcell.KeyF5:
+			t.uploadFile(pages, form, "wordlist", wordlistDropdowns, &t.wordlistOptions)
+			return nil
+		case tcell.KeyF6:
+			t.uploadFile(pages, form, "rule", []*tview.DropDown{rulesDropdown}, &t.ruleOptions)
+			return nil
+		case tcell.KeyF7:
+			t.pickFile(pages, "Mask file", func(file string) {
+				maskFileInput.SetText(file)
+			})
+			return nil
+		case tcell.KeyF8:
+			t.importHashes(pages, form)
+			return nil
 		case tcell.KeyF3:
 			t.refreshStatus(statusTable)
 			pages.SwitchToPage("status")
@@ -543,411 +1096,2776 @@ func (t *TUIApp) Run() {
 		return event
 	})
 
-	t.log("Hotkeys enabled: F2 (Main View), F3 (Status View), Ctrl+Q (Quit)")
+	t.log("Hotkeys enabled: F2 (Main View), F3 (Status View), F4 (Export Results), F5 (Upload Wordlist), F6 (Upload Rule), F7 (Mask File), F8 (Import Hashes), Ctrl+Q (Quit)")
+	if profile, err := t.config.Profile(t.profileName); err == nil {
+		t.log(fmt.Sprintf("Using profile %q: %s, %s", t.profileName, profile.URL, t.client.Route()))
+		for _, warning := range profile.warnings() {
+			t.log("[red]" + warning)
+		}
+	}
 
 	if err := t.app.SetRoot(pages, true).EnableMouse(true).Run(); err != nil {
 		panic(err)
 	}
 }
 
-// REMOVED detectHashType function
+// hashTypeMatch is a hash type detected for some hashes that the server
+// supports.
+type hashTypeMatch struct {
+	option string // the Hash Type option, "Name (mode)"
+	lines  int    // sampled lines that matched
+}
 
-func (t *TUIApp) refreshStatus(statusTable *tview.Table) {
-	t.log("Refreshing session statuses...")
-	go func() {
-		sessions, err := t.client.GetAllSessions()
-		if err != nil {
-			t.app.QueueUpdateDraw(func() {
-				t.log(fmt.Sprintf("[red]Error refreshing statuses: %v", err))
-			})
-			return
-		}
-		t.app.QueueUpdateDraw(func() {
-			statusTable.Clear()
-			headers := []string{"ID", "Name", "User", "State", "Progress", "Cracked"}
-			for i, h := range headers {
-				statusTable.SetCell(0, i, tview.NewTableCell(h).SetSelectable(false).SetTextColor(tview.Styles.SecondaryTextColor))
-			}
-			for i, s := range sessions {
-				crackedStr := fmt.Sprintf("%d/%d", s.Hashcat.CrackedPasswords, s.Hashcat.AllPasswords)
-				statusTable.SetCell(i+1, 0, tview.NewTableCell(fmt.Sprintf("%d", s.ID)))
-				statusTable.SetCell(i+1, 1, tview.NewTableCell(s.Name))
-				statusTable.SetCell(i+1, 2, tview.NewTableCell(s.Username))
-				statusTable.SetCell(i+1, 3, tview.NewTableCell(s.Hashcat.StateDescription))
-				statusTable.SetCell(i+1, 4, tview.NewTableCell(fmt.Sprintf("%.2f%%", s.Hashcat.Progress)))
-				statusTable.SetCell(i+1, 5, tview.NewTableCell(crackedStr))
+// detectHashTypes identifies newline-separated hashes and returns the
+// matching options (see hashTypeOf), most likely first, and the number of
+// lines sampled.
+func detectHashTypes(text string, usernames bool, options []string) ([]hashTypeMatch, int) {
+	candidates, lines := hashid.IdentifyText(text, usernames)
+	var matches []hashTypeMatch
+	for _, c := range candidates {
+		for _, option := range options {
+			if mode, ok := hashTypeOf(option); ok && mode == c.Mode {
+				matches = append(matches, hashTypeMatch{option: option, lines: c.Lines})
+				break
 			}
-			t.log("[green]Session statuses refreshed.")
-		})
-	}()
+		}
+	}
+	return matches, lines
 }
 
-func (t *TUIApp) loadInitialData(sessionDD, hashTypeDD, wordlistDD, rulesDD *tview.DropDown, form *tview.Form, resultsTable *tview.Table) {
-	t.log("Fetching options from server...")
-	sessions, err := t.client.GetAllSessions()
-	if err != nil {
-		t.log(fmt.Sprintf("[red]Error fetching sessions: %v", err))
-	} else {
-		t.sessions = sessions
+// hashTypeOptions formats the server's hash types as "Name (mode)" options.
+func hashTypeOptions(hashTypes []crackerjack.HashType) []string {
+	options := make([]string, 0, len(hashTypes))
+	for _, ht := range hashTypes {
+		options = append(options, fmt.Sprintf("%s (%s)", ht.Name, ht.Type))
 	}
+	return options
+}
 
-	hashTypes, _ := t.client.GetHashTypes()
-	wordlists, _ := t.client.GetWordlists()
-	rules, _ := t.client.GetRules()
-
-	t.app.QueueUpdateDraw(func() {
-		sessionOptions := []string{"New Session"}
-		for _, s := range t.sessions {
-			sessionOptions = append(sessionOptions, fmt.Sprintf("%s (ID: %d)", s.Name, s.ID))
-		}
-		sessionDD.SetOptions(sessionOptions, func(text string, index int) {
-			if index == 0 {
-				t.sessionID = 0
-				form.GetFormItemByLabel("Session Name").(*tview.InputField).SetText("")
-				t.displayResults(resultsTable, "")
-				t.log("Switched to new session mode.")
-			} else {
-				session := t.sessions[index-1]
-				t.sessionID = session.ID
-				t.log(fmt.Sprintf("Loading data for session %d...", t.sessionID))
-				go t.populateFormForSession(t.sessionID, form, hashTypeDD, wordlistDD, rulesDD, resultsTable)
+// importHashes lets the user pick a dump file, shows the formats it can be
+// read as, with the number of hashes each finds, and fills the form with the
+// hashes, usernames and hash type of the chosen one.
+func (t *TUIApp) importHashes(pages *tview.Pages, form *tview.Form) {
+	t.pickFile(pages, "Import hashes", func(file string) {
+		t.log(fmt.Sprintf("Reading %s...", tview.Escape(file)))
+		go func() {
+			data, err := os.ReadFile(file)
+			type candidate struct {
+				format  *importer.Format
+				summary importer.Summary
 			}
-		})
-
-		t.hashTypeOptions = []string{}
-		for _, ht := range hashTypes {
-			t.hashTypeOptions = append(t.hashTypeOptions, fmt.Sprintf("%s (%s)", ht.Name, ht.Type))
-		}
-		hashTypeDD.SetOptions(t.hashTypeOptions, nil)
-
-		t.wordlistOptions = []string{}
-		for _, wl := range wordlists {
-			t.wordlistOptions = append(t.wordlistOptions, wl.Name)
-		}
-		wordlistDD.SetOptions(t.wordlistOptions, nil)
+			var candidates []candidate
+			for i := range importer.Formats {
+				format := &importer.Formats[i]
+				summary, _ := format.Scan(bytes.NewReader(data))
+				if summary.Entries() > 0 {
+					candidates = append(candidates, candidate{format, summary})
+				}
+			}
+			sort.SliceStable(candidates, func(i, j int) bool {
+				return candidates[i].summary.Entries() > candidates[j].summary.Entries()
+			})
 
-		t.ruleOptions = []string{"None"}
-		for _, r := range rules {
-			t.ruleOptions = append(t.ruleOptions, r.Name)
-		}
-		rulesDD.SetOptions(t.ruleOptions, nil)
-		t.log("[green]Options fetched successfully.")
+			t.app.QueueUpdateDraw(func() {
+				switch {
+				case err != nil:
+					t.logError("Error reading hashes", err)
+					return
+				case len(candidates) == 0:
+					t.log(fmt.Sprintf("[yellow]No hashes of a known dump format found in %s.", tview.Escape(file)))
+					return
+				case pages.HasPage("dialog"):
+					return
+				}
+				list := tview.NewList()
+				list.SetBorder(true).SetTitle("Import As (Esc: Cancel)")
+				list.SetDoneFunc(func() {
+					pages.RemovePage("dialog")
+				})
+				for _, c := range candidates {
+					mode := c.summary.Mode()
+					hashType := mode
+					if _, option, err := lookupHashType(mode, t.hashTypeOptions, nil); err == nil && option != "" {
+						hashType = option
+					}
+					label := fmt.Sprintf("%s  [gray](%d hashes of %s)", c.format.Name, c.summary.Modes[mode], tview.Escape(hashType))
+					list.AddItem(label, tview.Escape(c.format.Description), 0, func() {
+						pages.RemovePage("dialog")
+						var hashes strings.Builder
+						n, _ := c.format.Convert(bytes.NewReader(data), &hashes, mode)
+						form.GetFormItemByLabel("Hashes").(*tview.TextArea).SetText(hashes.String(), false)
+						form.GetFormItemByLabel("Hashes Contain Usernames").(*tview.Checkbox).SetChecked(true)
+						form.GetFormItemByLabel("Hash Type").(*tview.InputField).SetText(hashType)
+						t.log(fmt.Sprintf("[green]Imported %d %s hashes with usernames from %s.", n, c.format.Name, tview.Escape(file)))
+						if other := c.summary.Entries() - n; other > 0 {
+							t.log(fmt.Sprintf("[yellow]Left out %d hashes of other types.", other))
+						}
+						if c.summary.Skipped > 0 {
+							t.log(fmt.Sprintf("Skipped %d lines without a usable hash.", c.summary.Skipped))
+						}
+					})
+				}
+				pages.AddPage("dialog", centered(list, 90, min(2*len(candidates)+2, 20)), true, true)
+			})
+		}()
 	})
 }
 
-func (t *TUIApp) populateFormForSession(id int, form *tview.Form, hashTypeDD, wordlistDD, rulesDD *tview.DropDown, resultsTable *tview.Table) {
-	sessionDetails, err := t.client.GetSession(id)
-	if err != nil {
-		t.log(fmt.Sprintf("[red]Error fetching details for session %d: %v", id, err))
+// pickDetectedType lists the hash types detected for the pasted hashes, most
+// likely first, and selects the chosen one in the Hash Type field.
+func (t *TUIApp) pickDetectedType(pages *tview.Pages, hashTypeInput *tview.InputField) {
+	if pages.HasPage("dialog") {
+		return
+	}
+	if len(t.detected) == 0 {
+		t.log("[yellow]No known hash type matches the pasted hashes.")
 		return
 	}
-	resultsStr, _ := t.client.DownloadResults(id)
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true).SetTitle("Detected Hash Types (Esc: Cancel)")
+	list.SetDoneFunc(func() {
+		pages.RemovePage("dialog")
+	})
+	for _, match := range t.detected {
+		label := fmt.Sprintf("%s  [gray](%d/%d lines)", tview.Escape(match.option), match.lines, t.detectedLines)
+		list.AddItem(label, "", 0, func() {
+			pages.RemovePage("dialog")
+			hashTypeInput.SetText(match.option)
+			t.log(fmt.Sprintf("Hash type set to %s.", tview.Escape(match.option)))
+		})
+	}
+	pages.AddPage("dialog", centered(list, 70, min(len(t.detected)+2, 20)), true, true)
+}
 
-	t.app.QueueUpdateDraw(func() {
-		form.GetFormItemByLabel("Session Name").(*tview.InputField).SetText(sessionDetails.Name)
+// Mask estimates beyond these limits ask for confirmation before the job is
+// started.
+const (
+	absurdRuntime  = 365 * 24 * time.Hour
+	absurdKeyspace = 1e16 // used when no speed is known for the hash type
+)
 
-		for i, opt := range t.hashTypeOptions {
-			if strings.Contains(opt, fmt.Sprintf("(%s)", sessionDetails.Hashcat.HashType)) {
-				hashTypeDD.SetCurrentOption(i)
-				break
-			}
-		}
+// maskEstimate is the size of the mask part of a job.
+type maskEstimate struct {
+	keyspace *big.Int
+	speed    float64 // hashes per second seen in an earlier session, or 0
+	perWord  bool    // hybrid modes: the keyspace applies to every word
+}
 
-		if sessionDetails.Hashcat.Mode == 0 { // Wordlist
-			form.GetFormItemByLabel("Attack Mode").(*tview.DropDown).SetCurrentOption(0)
-			for i, opt := range t.wordlistOptions {
-				if opt == sessionDetails.Hashcat.Wordlist {
-					wordlistDD.SetCurrentOption(i)
-					break
-				}
-			}
-			ruleSet := false
-			for i, opt := range t.ruleOptions {
-				if opt == sessionDetails.Hashcat.Rule {
-					rulesDD.SetCurrentOption(i)
-					ruleSet = true
-					break
-				}
-			}
-			if !ruleSet {
-				rulesDD.SetCurrentOption(0) // "None"
-			}
-		} else if sessionDetails.Hashcat.Mode == 3 { // Mask
-			form.GetFormItemByLabel("Attack Mode").(*tview.DropDown).SetCurrentOption(1)
-			form.GetFormItemByLabel("Mask").(*tview.InputField).SetText(sessionDetails.Hashcat.Mask)
-		}
+// seconds returns the estimated runtime, or 0 if the speed is unknown.
+func (e *maskEstimate) seconds() float64 {
+	if e.speed <= 0 {
+		return 0
+	}
+	keyspace, _ := new(big.Float).SetInt(e.keyspace).Float64()
+	return keyspace / e.speed
+}
 
-		t.displayResults(resultsTable, resultsStr)
-		t.log(fmt.Sprintf("[green]Successfully populated form with data from session %d.", id))
-	})
+// absurd reports whether the job would practically never finish.
+func (e *maskEstimate) absurd() bool {
+	if e.speed > 0 {
+		return e.seconds() > absurdRuntime.Seconds()
+	}
+	keyspace, _ := new(big.Float).SetInt(e.keyspace).Float64()
+	return keyspace > absurdKeyspace
 }
 
-// startJob is the main TUI logic for starting and monitoring a job.
-func (t *TUIApp) startJob(form *tview.Form, progress *tview.TextView, results *tview.Table) {
-	if t.isJobRunning {
-		t.log("[yellow]A job is already running.")
-		return
+func (e *maskEstimate) String() string {
+	text := "Keyspace " + formatKeyspace(e.keyspace)
+	if e.perWord {
+		text += " per word"
 	}
-	t.isJobRunning = true
-	t.log("[yellow]Starting/Updating job...")
+	if e.speed <= 0 {
+		return text + ", no speed known for this hash type"
+	}
+	runtime := "~" + formatSeconds(e.seconds())
+	if e.perWord {
+		runtime += " per word"
+	}
+	return fmt.Sprintf("%s, %s at %s", text, runtime, formatSpeed(e.speed))
+}
 
-	var err error
-	if t.sessionID == 0 {
-		sessionName := form.GetFormItemByLabel("Session Name").(*tview.InputField).GetText()
-		t.sessionID, err = t.client.CreateSession(sessionName)
+// maskFileCache holds the masks of the last mask file read by loadMaskFile.
+type maskFileCache struct {
+	path  string
+	masks []string
+	err   error
+}
+
+// loadMaskFile returns the masks of file, reading it only if it is not the
+// cached one, so that the estimate does not re-read it on every keystroke.
+// The Mask File field clears the cache whenever it changes.
+func (t *TUIApp) loadMaskFile(file string) ([]string, error) {
+	if t.maskFile.path != file {
+		masks, err := readMaskFile(file)
+		t.maskFile = maskFileCache{path: file, masks: masks, err: err}
+	}
+	return t.maskFile.masks, t.maskFile.err
+}
+
+// estimateMask estimates the mask part of the job configured in the form. It
+// returns nil if the selected attack mode does not use a mask.
+func (t *TUIApp) estimateMask(form *tview.Form) (*maskEstimate, error) {
+	_, attackMode := form.GetFormItemByLabel("Attack Mode").(*tview.DropDown).GetCurrentOption()
+	if !crackerjack.UsesMask(attackMode) {
+		return nil, nil
+	}
+	text := func(label string) string {
+		return form.GetFormItemByLabel(label).(*tview.InputField).GetText()
+	}
+
+	increment := text("Increment")
+	var minLen, maxLen int
+	if increment != "" {
+		var err error
+		if minLen, maxLen, err = parseIncrement(increment); err != nil {
+			return nil, err
+		}
+	}
+	charsets := mask.SplitCharsets(text("Charsets"))
+
+	var lines []string
+	if maskFile := strings.TrimSpace(text("Mask File")); maskFile != "" {
+		masks, err := t.loadMaskFile(maskFile)
 		if err != nil {
-			t.log(fmt.Sprintf("[red]Error creating session: %v", err))
-			t.isJobRunning = false
-			return
+			return nil, err
 		}
-		t.log(fmt.Sprintf("[green]New session created with ID: %d", t.sessionID))
+		lines = masks
+	} else if text("Mask") != "" {
+		// A single mask is never split into charset fields.
+		lines = []string{strings.ReplaceAll(text("Mask"), ",", `\,`)}
 	} else {
-		t.log(fmt.Sprintf("Updating existing session with ID: %d", t.sessionID))
+		return nil, nil
 	}
 
-	hashes := form.GetFormItemByLabel("Hashes").(*tview.TextArea).GetText()
-	if hashes != "" {
-		if err := t.client.UploadHashes(t.sessionID, hashes); err != nil {
-			t.log(fmt.Sprintf("[red]Error uploading hashes: %v", err))
-			t.isJobRunning = false
-			return
+	total := new(big.Int)
+	for _, line := range lines {
+		m, custom, err := mask.ParseLine(line)
+		if err != nil {
+			return nil, err
 		}
-		t.log("Hashes uploaded.")
-	} else {
-		t.log("No new hashes provided, keeping existing ones.")
+		if len(custom) == 0 {
+			custom = charsets
+		}
+		keyspace, err := mask.Keyspace(m, custom, increment != "", minLen, maxLen)
+		if err != nil {
+			if len(lines) > 1 {
+				return nil, fmt.Errorf("%s: %w", m, err)
+			}
+			return nil, err
+		}
+		total.Add(total, keyspace)
 	}
 
-	_, hashTypeStr := form.GetFormItemByLabel("Hash Type").(*tview.DropDown).GetCurrentOption()
-	htParts := strings.Split(strings.TrimSuffix(hashTypeStr, ")"), " (")
-	if len(htParts) < 2 {
-		t.log(fmt.Sprintf("[red]Invalid hash type selected: %s", hashTypeStr))
-		t.isJobRunning = false
+	estimate := &maskEstimate{keyspace: total, perWord: attackMode != crackerjack.ModeMask}
+	hashTypeStr := form.GetFormItemByLabel("Hash Type").(*tview.InputField).GetText()
+	if hashType, _, err := lookupHashType(hashTypeStr, t.hashTypeOptions, nil); err == nil {
+		estimate.speed = t.speedFor(hashType)
+	}
+	return estimate, nil
+}
+
+// speedFor returns the speed of the most recent session that cracked the
+// given hash type, or 0 if there is none.
+func (t *TUIApp) speedFor(hashType string) float64 {
+	speed, latest := 0.0, 0
+	for _, s := range t.sessions {
+		if s.Hashcat.HashType == hashType && s.Hashcat.Speed > 0 && s.ID > latest {
+			speed, latest = s.Hashcat.Speed, s.ID
+		}
+	}
+	return speed
+}
+
+// confirmStart calls start, after asking for confirmation if the mask
+// estimate says the job would practically never finish.
+func (t *TUIApp) confirmStart(pages *tview.Pages, form *tview.Form, start func()) {
+	if pages.HasPage("dialog") {
 		return
 	}
-	hashType := htParts[1]
-	if err := t.client.SetHashType(t.sessionID, hashType); err != nil {
-		t.log(fmt.Sprintf("[red]Error setting hash type: %v", err))
-		t.isJobRunning = false
+	estimate, err := t.estimateMask(form)
+	if err != nil || estimate == nil || !estimate.absurd() {
+		start()
 		return
 	}
-	t.log("Hash type set.")
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("This mask will practically never finish.\n\n%s\n\nStart the job anyway?", estimate)).
+		AddButtons([]string{"Start", "Cancel"}).
+		SetDoneFunc(func(_ int, label string) {
+			pages.RemovePage("dialog")
+			if label == "Start" {
+				start()
+			}
+		})
+	pages.AddPage("dialog", modal, true, true)
+}
 
-	_, attackMode := form.GetFormItemByLabel("Attack Mode").(*tview.DropDown).GetCurrentOption()
-	if err := t.client.SetMode(t.sessionID, attackMode); err != nil {
-		t.log(fmt.Sprintf("[red]Error setting mode: %v", err))
-		t.isJobRunning = false
+// confirmHashes checks the pasted hashes against the selected hash type. If
+// some lines are malformed, it logs them and asks whether to drop them before
+// calling start.
+func (t *TUIApp) confirmHashes(pages *tview.Pages, form *tview.Form, start func()) {
+	if pages.HasPage("dialog") {
+		return
+	}
+	hashesInput := form.GetFormItemByLabel("Hashes").(*tview.TextArea)
+	hashes := hashesInput.GetText()
+	hashTypeStr := form.GetFormItemByLabel("Hash Type").(*tview.InputField).GetText()
+	mode, option, err := lookupHashType(hashTypeStr, t.hashTypeOptions, t.pinnedHashTypes())
+	if hashes == "" || err != nil {
+		start()
+		return
+	}
+	usernames := form.GetFormItemByLabel("Hashes Contain Usernames").(*tview.Checkbox).IsChecked()
+	invalid, lines, err := hashid.ValidateLines(mode, strings.NewReader(hashes), usernames)
+	if err != nil || len(invalid) == 0 {
+		start()
 		return
 	}
-	t.log(fmt.Sprintf("Mode set to %s.", attackMode))
 
-	if attackMode == "wordlist" {
-		_, wordlist := form.GetFormItemByLabel("Wordlist").(*tview.DropDown).GetCurrentOption()
-		if err := t.client.SetWordlist(t.sessionID, wordlist); err != nil {
-			t.log(fmt.Sprintf("[red]Error setting wordlist: %v", err))
-			t.isJobRunning = false
-			return
-		}
-		t.log("Wordlist set.")
+	if option == "" {
+		option = mode
+	}
+	t.log(fmt.Sprintf("[red]%d of %d lines are not valid %s hashes:", len(invalid), lines, tview.Escape(option)))
+	for _, inv := range invalid[:min(maxReportedInvalid, len(invalid))] {
+		t.log("[red]  " + tview.Escape(inv.Error()))
+	}
+	if len(invalid) > maxReportedInvalid {
+		t.log(fmt.Sprintf("[red]  ... and %d more", len(invalid)-maxReportedInvalid))
+	}
+
+	text := fmt.Sprintf("%d of %d lines are not valid %s hashes (see the log).\n\nDrop them before uploading?", len(invalid), lines, option)
+	buttons := []string{"Drop Invalid", "Upload All", "Cancel"}
+	if len(invalid) == lines {
+		text = fmt.Sprintf("None of the lines are valid %s hashes (see the log).\n\nUpload them anyway?", option)
+		buttons = buttons[1:]
+	}
+	modal := tview.NewModal().
+		SetText(text).
+		AddButtons(buttons).
+		SetDoneFunc(func(_ int, label string) {
+			pages.RemovePage("dialog")
+			switch label {
+			case "Drop Invalid":
+				valid, _ := io.ReadAll(withoutLines(strings.NewReader(hashes), invalid))
+				hashesInput.SetText(string(valid), false)
+				t.log(fmt.Sprintf("Dropped %d invalid lines.", len(invalid)))
+				start()
+			case "Upload All":
+				start()
+			}
+		})
+	pages.AddPage("dialog", modal, true, true)
+}
 
-		_, rule := form.GetFormItemByLabel("Rules").(*tview.DropDown).GetCurrentOption()
-		if rule != "None" {
-			if err := t.client.SetRule(t.sessionID, rule); err != nil {
-				t.log(fmt.Sprintf("[red]Error setting rule: %v", err))
-				t.isJobRunning = false
-				return
+// hashTypeOf extracts the hashcat mode from a Hash Type option, which has
+// the form "Name (mode)".
+func hashTypeOf(option string) (string, bool) {
+	open := strings.LastIndex(option, " (")
+	if open < 0 || !strings.HasSuffix(option, ")") {
+		return "", false
+	}
+	return option[open+2 : len(option)-1], true
+}
+
+// lookupHashType resolves a hash type given as a mode number, an option
+// ("Name (mode)") or a search term such as "ntlm" to a mode, and the option
+// for it if there is one. A search term must match one option better than
+// all others; pinned only orders the matches listed when it does not.
+func lookupHashType(query string, options, pinned []string) (mode, option string, err error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return "", "", errors.New("no hash type selected")
+	}
+	if _, err := strconv.Atoi(query); err == nil {
+		for _, option := range options {
+			if m, _ := hashTypeOf(option); m == query {
+				return query, option, nil
 			}
-			t.log("Rule set.")
-		}
-	} else { // mask
-		mask := form.GetFormItemByLabel("Mask").(*tview.InputField).GetText()
-		if err := t.client.SetMask(t.sessionID, mask); err != nil {
-			t.log(fmt.Sprintf("[red]Error setting mask: %v", err))
-			t.isJobRunning = false
-			return
 		}
-		t.log("Mask set.")
+		return query, "", nil
 	}
 
-	if err := t.client.StartJob(t.sessionID); err != nil {
-		t.log(fmt.Sprintf("[red]Error starting job: %v", err))
-		t.isJobRunning = false
-		return
+	matches := searchHashTypes(query, options, pinned)
+	if len(matches) == 0 {
+		return "", "", fmt.Errorf("no hash type matches %q", query)
+	}
+	lower := strings.ToLower(query)
+	if len(matches) > 1 && hashTypeScore(lower, matches[1]) == hashTypeScore(lower, matches[0]) {
+		shown := matches[:min(8, len(matches))]
+		return "", "", fmt.Errorf("hash type %q is ambiguous, it matches %s; use the mode number", query, strings.Join(shown, ", "))
 	}
-	t.log("[green]Job started successfully! Polling for status...")
+	mode, _ = hashTypeOf(matches[0])
+	return mode, matches[0], nil
+}
 
-	go func() {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-		for range ticker.C {
-			if !t.isJobRunning {
-				return
+// searchHashTypes returns the options (see hashTypeOf) that match query,
+// best match first. Among equally good matches, the pinned modes come first
+// in their given order. An empty query returns just the pinned options, or
+// all options if nothing is pinned.
+func searchHashTypes(query string, options, pinned []string) []string {
+	query = strings.ToLower(strings.TrimSpace(query))
+	type match struct {
+		option string
+		score  int
+		pin    int // position in pinned, or len(pinned)
+	}
+	var matches []match
+	for _, option := range options {
+		mode, _ := hashTypeOf(option)
+		pin := slices.Index(pinned, mode)
+		if pin < 0 {
+			if query == "" && len(pinned) > 0 {
+				continue
 			}
-			state, err := t.client.GetState(t.sessionID)
-			if err != nil {
-				t.app.QueueUpdateDraw(func() {
-					t.log(fmt.Sprintf("[red]Error polling status: %v", err))
-				})
-				t.isJobRunning = false
-				return
+			pin = len(pinned)
+		}
+		score := 1
+		if query != "" {
+			if score = hashTypeScore(query, option); score == 0 {
+				continue
 			}
+		}
+		matches = append(matches, match{option, score, pin})
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].pin < matches[j].pin
+	})
+	found := make([]string, len(matches))
+	for i, m := range matches {
+		found[i] = m.option
+	}
+	return found
+}
 
-			t.app.QueueUpdateDraw(func() {
-				t.log(fmt.Sprintf("Polling: Status='%s', Progress=%.2f%%", state.Description, state.Progress))
-				progressText := fmt.Sprintf("%s - %.2f%%", state.Description, state.Progress)
-				progress.SetText(progressText)
-			})
+// hashTypeScore rates how well a lower-case query matches a hash type
+// option, from an exact mode or name down to the letters of the query
+// appearing in order in the name. It returns 0 if the option does not match.
+func hashTypeScore(query, option string) int {
+	mode, _ := hashTypeOf(option)
+	name := strings.ToLower(strings.TrimSuffix(option, " ("+mode+")"))
+	switch {
+	case query == mode, query == strings.ToLower(option):
+		return 100
+	case query == name:
+		return 90
+	case mode != "" && strings.HasPrefix(mode, query):
+		return 70
+	case strings.HasPrefix(name, query):
+		return 60
+	case hasWordPrefix(name, query):
+		return 50
+	case strings.Contains(name, query):
+		return 40
+	case isSubsequence(query, name):
+		return 10
+	}
+	return 0
+}
 
-			if state.State == 2 || state.State == 3 || state.State == 5 {
-				t.app.QueueUpdateDraw(func() {
-					t.log("[green]Job finished. Fetching results...")
-					resultsStr, err := t.client.DownloadResults(t.sessionID)
-					if err != nil {
-						t.log(fmt.Sprintf("[red]Error fetching results: %v", err))
-					} else {
-						t.displayResults(results, resultsStr)
-					}
-					t.isJobRunning = false
-				})
-				return
-			}
+// hasWordPrefix reports whether a word of s, delimited by anything but
+// letters and digits, starts with prefix.
+func hasWordPrefix(s, prefix string) bool {
+	for i := 1; i < len(s); i++ {
+		if isAlnum(s[i-1]) || !strings.HasPrefix(s[i:], prefix) {
+			continue
 		}
-	}()
+		return true
+	}
+	return false
 }
 
-func (t *TUIApp) displayResults(table *tview.Table, resultsStr string) {
-	table.Clear()
-	table.SetCell(0, 0, tview.NewTableCell("Hash").SetSelectable(false).SetTextColor(tview.Styles.SecondaryTextColor))
-	table.SetCell(0, 1, tview.NewTableCell("Plaintext").SetSelectable(false).SetTextColor(tview.Styles.SecondaryTextColor))
-
-	lines := strings.Split(resultsStr, "\n")
+func isAlnum(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
 
-	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
-		t.log("No cracked passwords found for this session.")
-		return
+// isSubsequence reports whether the bytes of sub appear in s in order.
+func isSubsequence(sub, s string) bool {
+	for i := 0; i < len(s) && sub != ""; i++ {
+		if s[i] == sub[0] {
+			sub = sub[1:]
+		}
 	}
+	return sub == ""
+}
 
-	rowCount := 1
-	for _, line := range lines {
-		if line == "" {
-			continue
+// pinnedHashTypes lists the favourite hash types followed by the recently
+// used ones that are not favourites.
+func pinnedHashTypes(favorites, recent []string) []string {
+	pinned := slices.Clone(favorites)
+	for _, mode := range recent {
+		if !slices.Contains(pinned, mode) {
+			pinned = append(pinned, mode)
 		}
-		parts := strings.SplitN(line, ":", 2)
-		if len(parts) < 2 {
-			continue
-		}
-		table.SetCell(rowCount, 0, tview.NewTableCell(parts[0]).SetTextColor(tview.Styles.PrimaryTextColor))
-		table.SetCell(rowCount, 1, tview.NewTableCell(parts[1]).SetTextColor(tview.Styles.TertiaryTextColor))
-		rowCount++
 	}
-	t.log(fmt.Sprintf("Displayed %d results.", rowCount-1))
+	return pinned
 }
 
-// =================================================================================
-// 4. CLI (Command-Line Interface)
-// =================================================================================
-
-func runCLI(client *APIClient, args *cliArgs) {
+// pinnedHashTypes returns the hash types listed first in the picker: the
+// current profile's favourites, then the recently used ones.
+func (t *TUIApp) pinnedHashTypes() []string {
+	var favorites []string
+	if profile, err := t.config.Profile(t.profileName); err == nil {
+		favorites = profile.FavoriteHashTypes
+	}
+	return pinnedHashTypes(favorites, t.recentHashTypes)
+}
+
+// formatKeyspace formats a candidate count, in scientific notation when it
+// is large.
+func formatKeyspace(n *big.Int) string {
+	if n.IsInt64() && n.Int64() < 1e9 {
+		return n.String()
+	}
+	f, _ := new(big.Float).SetInt(n).Float64()
+	return fmt.Sprintf("%.2e", f)
+}
+
+// formatSeconds formats a runtime in seconds with a unit that fits it.
+func formatSeconds(seconds float64) string {
+	switch {
+	case seconds < 1:
+		return "under a second"
+	case seconds < 3600:
+		return (time.Duration(seconds) * time.Second).String()
+	case seconds < 48*3600:
+		return fmt.Sprintf("%.1f hours", seconds/3600)
+	case seconds < 365*24*3600:
+		return fmt.Sprintf("%.0f days", seconds/(24*3600))
+	default:
+		return fmt.Sprintf("%.3g years", seconds/(365*24*3600))
+	}
+}
+
+// formatSpeed formats a cracking speed in hashes per second.
+func formatSpeed(speed float64) string {
+	units := []string{"H/s", "kH/s", "MH/s", "GH/s", "TH/s", "PH/s"}
+	unit := 0
+	for speed >= 1000 && unit < len(units)-1 {
+		speed /= 1000
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", speed, units[unit])
+}
+
+// showModeFields replaces the form items after the Attack Mode dropdown with
+// fields.
+func showModeFields(form *tview.Form, fields []tview.FormItem) {
+	modeIndex := form.GetFormItemIndex("Attack Mode")
+	for form.GetFormItemCount() > modeIndex+1 {
+		form.RemoveFormItem(modeIndex + 1)
+	}
+	for _, field := range fields {
+		form.AddFormItem(field)
+	}
+}
+
+// switchProfile points the TUI at a different server profile.
+func (t *TUIApp) switchProfile(name string) error {
+	profile, err := t.config.Profile(name)
+	if err != nil {
+		return err
+	}
+	effective := *profile
+	if err := effective.resolveAPIKey(false); err != nil {
+		return err
+	}
+	if err := effective.Validate(); err != nil {
+		return err
+	}
+	profile = &effective
+	if err := t.connect(profile); err != nil {
+		return err
+	}
+	t.profileName = name
+	t.sessionID = 0
+	// Forget the old server's sessions, so that nothing refers to them if
+	// fetching the new server's fails.
+	t.sessions = nil
+	t.sessionWordlists = nil
+	t.usernames = false
+	t.log(fmt.Sprintf("Switched to profile %q (%s, %s).", name, profile.URL, t.client.Route()))
+	for _, warning := range profile.warnings() {
+		t.log("[red]" + warning)
+	}
+	return nil
+}
+
+func (t *TUIApp) refreshStatus(statusTable *tview.Table) {
+	t.log("Refreshing session statuses...")
+	go func() {
+		sessions, err := t.client.GetAllSessions(t.ctx)
+		if err != nil {
+			t.app.QueueUpdateDraw(func() {
+				t.logError("Error refreshing statuses", err)
+			})
+			return
+		}
+		t.app.QueueUpdateDraw(func() {
+			statusTable.Clear()
+			headers := []string{"ID", "Name", "User", "State", "Progress", "Cracked"}
+			for i, h := range headers {
+				statusTable.SetCell(0, i, tview.NewTableCell(h).SetSelectable(false).SetTextColor(tview.Styles.SecondaryTextColor))
+			}
+			for i, s := range sessions {
+				crackedStr := fmt.Sprintf("%d/%d", s.Hashcat.CrackedPasswords, s.Hashcat.AllPasswords)
+				statusTable.SetCell(i+1, 0, tview.NewTableCell(fmt.Sprintf("%d", s.ID)).SetReference(s.ID))
+				statusTable.SetCell(i+1, 1, tview.NewTableCell(s.Name))
+				statusTable.SetCell(i+1, 2, tview.NewTableCell(s.Username))
+				statusTable.SetCell(i+1, 3, tview.NewTableCell(s.Hashcat.StateDescription))
+				statusTable.SetCell(i+1, 4, tview.NewTableCell(fmt.Sprintf("%.2f%%", s.Hashcat.Progress)))
+				statusTable.SetCell(i+1, 5, tview.NewTableCell(crackedStr))
+			}
+			t.log("[green]Session statuses refreshed.")
+		})
+	}()
+}
+
+// sessionAction sends a job control action to the session selected in the
+// status table, then refreshes the table.
+func (t *TUIApp) sessionAction(statusTable *tview.Table, action string) {
+	id, _, ok := t.selectedSession(statusTable)
+	if !ok {
+		return
+	}
+	do := jobActions(t.client)[action]
+	t.log(fmt.Sprintf("Sending %s to session %d...", action, id))
+	go func() {
+		err := do(t.ctx, id)
+		t.app.QueueUpdateDraw(func() {
+			if err != nil {
+				t.logError(fmt.Sprintf("Error sending %s to session %d", action, id), err)
+				return
+			}
+			t.log(fmt.Sprintf("[green]Session %d: %s sent.", id, action))
+			t.refreshStatus(statusTable)
+		})
+	}()
+}
+
+// selectedSession returns the ID and name of the session selected in the
+// status table, logging a hint if there is none.
+func (t *TUIApp) selectedSession(statusTable *tview.Table) (int, string, bool) {
+	row, _ := statusTable.GetSelection()
+	id, ok := statusTable.GetCell(row, 0).GetReference().(int)
+	if !ok {
+		t.log("[yellow]Select a session first.")
+		return 0, "", false
+	}
+	return id, statusTable.GetCell(row, 1).Text, true
+}
+
+// confirmDelete asks for confirmation, then deletes the session selected in
+// the status table.
+func (t *TUIApp) confirmDelete(pages *tview.Pages, statusTable *tview.Table) {
+	id, name, ok := t.selectedSession(statusTable)
+	if !ok {
+		return
+	}
+	if id == t.sessionID && t.isJobRunning {
+		t.log("[yellow]Cannot delete the session whose job is being monitored.")
+		return
+	}
+	modal := tview.NewModal().
+		SetText(fmt.Sprintf("Delete session %d (%s)?\n\nIts hashes and results are removed from the server.", id, name)).
+		AddButtons([]string{"Delete", "Cancel"}).
+		SetDoneFunc(func(_ int, label string) {
+			pages.RemovePage("dialog")
+			if label != "Delete" {
+				return
+			}
+			t.log(fmt.Sprintf("Deleting session %d...", id))
+			go func() {
+				err := t.client.DeleteSession(t.ctx, id)
+				t.app.QueueUpdateDraw(func() {
+					if err != nil {
+						t.logError(fmt.Sprintf("Error deleting session %d", id), err)
+						return
+					}
+					if id == t.sessionID {
+						t.sessionID = 0
+					}
+					t.log(fmt.Sprintf("[green]Session %d deleted.", id))
+					t.refreshStatus(statusTable)
+				})
+			}()
+		})
+	pages.AddPage("dialog", modal, true, true)
+}
+
+// renameSession shows a dialog to rename the session selected in the status
+// table.
+func (t *TUIApp) renameSession(pages *tview.Pages, statusTable *tview.Table) {
+	id, name, ok := t.selectedSession(statusTable)
+	if !ok {
+		return
+	}
+	form := tview.NewForm()
+	form.AddInputField("New Name", name, 40, nil, nil).
+		AddButton("Rename", func() {
+			newName := strings.TrimSpace(form.GetFormItemByLabel("New Name").(*tview.InputField).GetText())
+			pages.RemovePage("dialog")
+			if newName == "" || newName == name {
+				return
+			}
+			go func() {
+				err := t.client.RenameSession(t.ctx, id, newName)
+				t.app.QueueUpdateDraw(func() {
+					if err != nil {
+						t.logError(fmt.Sprintf("Error renaming session %d", id), err)
+						return
+					}
+					t.log(fmt.Sprintf("[green]Session %d renamed to %q.", id, newName))
+					t.refreshStatus(statusTable)
+				})
+			}()
+		}).
+		AddButton("Cancel", func() {
+			pages.RemovePage("dialog")
+		})
+	form.SetBorder(true).SetTitle(fmt.Sprintf("Rename Session %d", id))
+	pages.AddPage("dialog", centered(form, 60, 7), true, true)
+}
+
+// exportResults shows a dialog to download one kind of results for the
+// current session and save it to a local file.
+func (t *TUIApp) exportResults(pages *tview.Pages) {
+	if t.sessionID == 0 {
+		t.log("[yellow]Load or start a session before exporting results.")
+		return
+	}
+	if pages.HasPage("dialog") {
+		return
+	}
+	id := t.sessionID
+	defaultFile := func(kind string) string {
+		return fmt.Sprintf("session-%d-%s.txt", id, kind)
+	}
+
+	kinds := make([]string, len(crackerjack.ResultsKinds))
+	for i, kind := range crackerjack.ResultsKinds {
+		kinds[i] = string(kind)
+	}
+	form := tview.NewForm()
+	form.AddInputField("File", defaultFile(kinds[0]), 40, nil, nil)
+	form.AddDropDown("Type", kinds, 0, func(option string, _ int) {
+		if item := form.GetFormItemByLabel("File"); item != nil {
+			item.(*tview.InputField).SetText(defaultFile(option))
+		}
+	})
+	form.AddButton("Export", func() {
+		_, option := form.GetFormItemByLabel("Type").(*tview.DropDown).GetCurrentOption()
+		file := strings.TrimSpace(form.GetFormItemByLabel("File").(*tview.InputField).GetText())
+		pages.RemovePage("dialog")
+		if file == "" {
+			return
+		}
+		kind := crackerjack.ResultsKind(option)
+		t.log(fmt.Sprintf("Exporting %s results of session %d...", kind, id))
+		go func() {
+			results, err := t.client.DownloadResults(t.ctx, id, kind)
+			if err == nil {
+				err = os.WriteFile(file, []byte(results), 0600)
+			}
+			t.app.QueueUpdateDraw(func() {
+				if err != nil {
+					t.logError("Error exporting results", err)
+					return
+				}
+				t.log(fmt.Sprintf("[green]Exported %d lines to %s.", countLines(results), file))
+			})
+		}()
+	}).AddButton("Cancel", func() {
+		pages.RemovePage("dialog")
+	})
+	form.SetBorder(true).SetTitle(fmt.Sprintf("Export Results of Session %d", id))
+	pages.AddPage("dialog", centered(form, 64, 9), true, true)
+}
+
+// uploadFile lets the user pick a local wordlist or rule file and uploads it
+// to the server's shared library. The file is added to *options, the options
+// of dropdowns, and selected in the first of dropdowns shown in the form.
+// Wordlists can instead be uploaded to the current session only (see
+// addSessionWordlist).
+func (t *TUIApp) uploadFile(pages *tview.Pages, form *tview.Form, kind string, dropdowns []*tview.DropDown, options *[]string) {
+	t.pickFile(pages, "Upload "+kind, func(file string) {
+		if kind != "wordlist" {
+			t.uploadToLibrary(form, kind, file, dropdowns, options)
+			return
+		}
+		session := "New Session"
+		if t.sessionID != 0 {
+			session = fmt.Sprintf("Session %d", t.sessionID)
+		}
+		modal := tview.NewModal().
+			SetText(fmt.Sprintf("Upload %s to the shared library, or only to the current session?", filepath.Base(file))).
+			AddButtons([]string{"Shared Library", session, "Cancel"}).
+			SetDoneFunc(func(_ int, label string) {
+				pages.RemovePage("dialog")
+				switch label {
+				case "Shared Library":
+					t.uploadToLibrary(form, kind, file, dropdowns, options)
+				case session:
+					t.addSessionWordlist(form, file, dropdowns)
+				}
+			})
+		pages.AddPage("dialog", modal, true, true)
+	})
+}
+
+// uploadToLibrary uploads a wordlist or rule file to the shared library and
+// selects it, see uploadFile.
+func (t *TUIApp) uploadToLibrary(form *tview.Form, kind, file string, dropdowns []*tview.DropDown, options *[]string) {
+	upload := t.client.UploadWordlist
+	if kind == "rule" {
+		upload = t.client.UploadRule
+	}
+	t.log(fmt.Sprintf("Uploading %s %s...", kind, tview.Escape(file)))
+	go func() {
+		name, err := uploadLocalFile(t.ctx, file, "", upload)
+		t.app.QueueUpdateDraw(func() {
+			if err != nil {
+				t.logError("Error uploading "+kind, err)
+				return
+			}
+			index := slices.Index(*options, name)
+			if index < 0 {
+				*options = append(*options, name)
+				index = len(*options) - 1
+				if kind == "wordlist" {
+					t.setWordlistOptions(dropdowns)
+				} else {
+					for _, dropdown := range dropdowns {
+						dropdown.SetOptions(*options, nil)
+					}
+				}
+			}
+			for _, dropdown := range dropdowns {
+				if form.GetFormItemIndex(dropdown.GetLabel()) >= 0 {
+					dropdown.SetCurrentOption(index)
+					break
+				}
+			}
+			t.log(fmt.Sprintf("[green]Uploaded and selected %s %s.", kind, tview.Escape(name)))
+		})
+	}()
+}
+
+// addSessionWordlist adds a local wordlist to the current session and
+// selects it in the Wordlist dropdown, the first of dropdowns. It is uploaded
+// right away if the session exists, or by startJob once it has been created.
+func (t *TUIApp) addSessionWordlist(form *tview.Form, file string, dropdowns []*tview.DropDown) {
+	name := filepath.Base(file)
+	sessionID := t.sessionID
+	add := func(pending string) {
+		t.sessionWordlists = slices.DeleteFunc(t.sessionWordlists, func(wl sessionWordlist) bool {
+			return wl.name == name
+		})
+		t.sessionWordlists = append(t.sessionWordlists, sessionWordlist{name: name, file: pending})
+		t.setWordlistOptions(dropdowns)
+		dropdowns[0].SetCurrentOption(len(t.wordlistOptions) + len(t.sessionWordlists) - 1)
+		if form.GetFormItemIndex(dropdowns[0].GetLabel()) < 0 {
+			t.log("[yellow]Session wordlists are only used by the wordlist and hybrid attack modes.")
+		}
+	}
+	if sessionID == 0 {
+		add(file)
+		t.log(fmt.Sprintf("[green]Selected wordlist %s; it will be uploaded to the new session when the job starts.", tview.Escape(name)))
+		return
+	}
+
+	t.log(fmt.Sprintf("Uploading wordlist %s to session %d...", tview.Escape(file), sessionID))
+	go func() {
+		_, err := uploadLocalFile(t.ctx, file, name, func(ctx context.Context, name string, r io.Reader) error {
+			return t.client.UploadSessionWordlist(ctx, sessionID, name, r)
+		})
+		t.app.QueueUpdateDraw(func() {
+			if err != nil {
+				t.logError("Error uploading wordlist", err)
+				return
+			}
+			if t.sessionID != sessionID {
+				t.log(fmt.Sprintf("Uploaded wordlist %s to session %d.", tview.Escape(name), sessionID))
+				return
+			}
+			add("")
+			t.log(fmt.Sprintf("[green]Uploaded wordlist %s to session %d and selected it.", tview.Escape(name), sessionID))
+		})
+	}()
+}
+
+// setWordlistOptions fills the wordlist dropdowns with the shared wordlists.
+// The Wordlist dropdown, the first, also lists the session's wordlists, which
+// the combinator mode cannot use.
+func (t *TUIApp) setWordlistOptions(dropdowns []*tview.DropDown) {
+	options := slices.Clone(t.wordlistOptions)
+	for _, wl := range t.sessionWordlists {
+		options = append(options, wl.name+sessionWordlistSuffix)
+	}
+	dropdowns[0].SetOptions(options, nil)
+	for _, dropdown := range dropdowns[1:] {
+		dropdown.SetOptions(t.wordlistOptions, nil)
+	}
+}
+
+// pickFile shows a dialog for browsing the local file system, starting in the
+// working directory, and calls onPick with the path of the chosen file.
+func (t *TUIApp) pickFile(pages *tview.Pages, title string, onPick func(file string)) {
+	if pages.HasPage("dialog") {
+		return
+	}
+	list := tview.NewList().ShowSecondaryText(false)
+	list.SetBorder(true)
+	list.SetDoneFunc(func() {
+		pages.RemovePage("dialog")
+	})
+
+	var show func(dir string)
+	show = func(dir string) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.logError("Error reading directory", err)
+			return
+		}
+		list.Clear()
+		list.SetTitle(fmt.Sprintf("%s: %s (Esc: Cancel)", title, tview.Escape(dir)))
+		list.AddItem("../", "", 0, func() {
+			show(filepath.Dir(dir))
+		})
+		for _, entry := range entries {
+			if entry.IsDir() {
+				subdir := filepath.Join(dir, entry.Name())
+				list.AddItem(tview.Escape(entry.Name())+"/", "", 0, func() {
+					show(subdir)
+				})
+			}
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			file := filepath.Join(dir, entry.Name())
+			label := tview.Escape(entry.Name())
+			if info, err := entry.Info(); err == nil {
+				label = fmt.Sprintf("%s  [gray](%d bytes)", label, info.Size())
+			}
+			list.AddItem(label, "", 0, func() {
+				pages.RemovePage("dialog")
+				onPick(file)
+			})
+		}
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		dir = "."
+	}
+	show(dir)
+	pages.AddPage("dialog", centered(list, 80, 24), true, true)
+}
+
+// uploadProgress describes how much of an upload of total bytes is done.
+// total may be zero if unknown.
+func uploadProgress(sent, total int64) string {
+	if total <= 0 {
+		return formatBytes(sent)
+	}
+	return fmt.Sprintf("%s / %s (%.1f%%)", formatBytes(sent), formatBytes(total), float64(sent)*100/float64(total))
+}
+
+// formatBytes formats a byte count with a binary unit, e.g. "1.5 GiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// countLines counts the non-empty lines in s.
+func countLines(s string) int {
+	n := 0
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// centered places p in the middle of the screen at the given size, for use
+// as a dialog page.
+func centered(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(nil, 0, 1, false).
+			AddItem(p, height, 1, true).
+			AddItem(nil, 0, 1, false), width, 1, true).
+		AddItem(nil, 0, 1, false)
+}
+
+func (t *TUIApp) loadInitialData(sessionDD *tview.DropDown, hashTypeInput *tview.InputField, wordlistDDs []*tview.DropDown, rulesDD *tview.DropDown, form *tview.Form, resultsTable *tview.Table) {
+	t.log("Fetching options from server...")
+	sessions, err := t.client.GetAllSessions(t.ctx)
+	if err != nil {
+		t.logError("Error fetching sessions", err)
+	} else {
+		t.sessions = sessions
+	}
+
+	hashTypes, _ := t.client.GetHashTypes(t.ctx)
+	wordlists, _ := t.client.GetWordlists(t.ctx)
+	rules, _ := t.client.GetRules(t.ctx)
+
+	t.app.QueueUpdateDraw(func() {
+		sessionOptions := []string{"New Session"}
+		for _, s := range t.sessions {
+			sessionOptions = append(sessionOptions, fmt.Sprintf("%s (ID: %d)", s.Name, s.ID))
+		}
+		sessionDD.SetOptions(sessionOptions, func(text string, index int) {
+			if len(t.sessionWordlists) > 0 {
+				t.sessionWordlists = nil
+				t.setWordlistOptions(wordlistDDs)
+			}
+			if index == 0 {
+				t.sessionID = 0
+				form.GetFormItemByLabel("Session Name").(*tview.InputField).SetText("")
+				t.usernames = false
+				t.displayResults(resultsTable, "", false)
+				t.log("Switched to new session mode.")
+			} else {
+				session := t.sessions[index-1]
+				t.sessionID = session.ID
+				t.log(fmt.Sprintf("Loading data for session %d...", t.sessionID))
+				go t.populateFormForSession(t.sessionID, form, hashTypeInput, wordlistDDs[0], rulesDD, resultsTable)
+			}
+		})
+
+		t.hashTypeOptions = hashTypeOptions(hashTypes)
+		t.updateDetection()
+
+		t.wordlistOptions = []string{}
+		for _, wl := range wordlists {
+			t.wordlistOptions = append(t.wordlistOptions, wl.Name)
+		}
+		t.setWordlistOptions(wordlistDDs)
+
+		t.ruleOptions = []string{"None"}
+		for _, r := range rules {
+			t.ruleOptions = append(t.ruleOptions, r.Name)
+		}
+		rulesDD.SetOptions(t.ruleOptions, nil)
+		t.updateEstimate()
+		t.log("[green]Options fetched successfully.")
+	})
+}
+
+func (t *TUIApp) populateFormForSession(id int, form *tview.Form, hashTypeInput *tview.InputField, wordlistDD, rulesDD *tview.DropDown, resultsTable *tview.Table) {
+	sessionDetails, err := t.client.GetSession(t.ctx, id)
+	if err != nil {
+		t.logError(fmt.Sprintf("Error fetching details for session %d", id), err)
+		return
+	}
+	resultsStr, _ := t.client.DownloadResults(t.ctx, id, crackerjack.ResultsCracked)
+
+	t.app.QueueUpdateDraw(func() {
+		form.GetFormItemByLabel("Session Name").(*tview.InputField).SetText(sessionDetails.Name)
+
+		hashType := sessionDetails.Hashcat.HashType
+		if _, option, err := lookupHashType(hashType, t.hashTypeOptions, nil); err == nil && option != "" {
+			hashType = option
+		}
+		hashTypeInput.SetText(hashType)
+
+		mode, ok := sessionDetails.Hashcat.AttackMode()
+		if !ok {
+			t.log(fmt.Sprintf("[yellow]Session %d uses hashcat attack mode %d, which this client does not support.", id, sessionDetails.Hashcat.Mode))
+		} else {
+			form.GetFormItemByLabel("Attack Mode").(*tview.DropDown).SetCurrentOption(slices.Index(crackerjack.Modes, mode))
+		}
+		if crackerjack.UsesWordlist(mode) {
+			for i, opt := range t.wordlistOptions {
+				if opt == sessionDetails.Hashcat.Wordlist {
+					wordlistDD.SetCurrentOption(i)
+					break
+				}
+			}
+		}
+		if mode == crackerjack.ModeCombinator {
+			hc := sessionDetails.Hashcat
+			for _, side := range []struct{ label, wordlist, rule string }{
+				{"Left", hc.LeftWordlist, hc.LeftRule},
+				{"Right", hc.RightWordlist, hc.RightRule},
+			} {
+				if i := slices.Index(t.wordlistOptions, side.wordlist); i >= 0 {
+					form.GetFormItemByLabel(side.label + " Wordlist").(*tview.DropDown).SetCurrentOption(i)
+				}
+				form.GetFormItemByLabel(side.label + " Rule").(*tview.InputField).SetText(side.rule)
+			}
+		}
+		if mode == crackerjack.ModeWordlist {
+			ruleSet := false
+			for i, opt := range t.ruleOptions {
+				if opt == sessionDetails.Hashcat.Rule {
+					rulesDD.SetCurrentOption(i)
+					ruleSet = true
+					break
+				}
+			}
+			if !ruleSet {
+				rulesDD.SetCurrentOption(0) // "None"
+			}
+		}
+		if crackerjack.UsesMask(mode) {
+			hc := sessionDetails.Hashcat
+			form.GetFormItemByLabel("Mask").(*tview.InputField).SetText(hc.Mask)
+			form.GetFormItemByLabel("Mask File").(*tview.InputField).SetText("")
+			form.GetFormItemByLabel("Charsets").(*tview.InputField).SetText(mask.JoinCharsets(hc.Charsets))
+			increment := ""
+			if hc.Increment {
+				increment = formatIncrement(hc.IncrementMin, hc.IncrementMax)
+			}
+			form.GetFormItemByLabel("Increment").(*tview.InputField).SetText(increment)
+			if len(hc.Masks) > 0 {
+				t.log(fmt.Sprintf("[yellow]Session %d runs a list of %d masks; pick the mask file again (F7) before updating the job to keep it.", id, len(hc.Masks)))
+			}
+		}
+
+		t.usernames = sessionDetails.ContainsUsernames
+		form.GetFormItemByLabel("Hashes Contain Usernames").(*tview.Checkbox).SetChecked(t.usernames)
+		t.displayResults(resultsTable, resultsStr, t.usernames)
+		t.log(fmt.Sprintf("[green]Successfully populated form with data from session %d.", id))
+	})
+}
+
+// startJob is the main TUI logic for starting and monitoring a job. It
+// reads the form on the UI goroutine and then sets up, starts and polls the
+// job in the background, so that long hash uploads do not block the UI.
+func (t *TUIApp) startJob(form *tview.Form, progress *tview.TextView, results *tview.Table) {
+	if t.isJobRunning {
+		t.log("[yellow]A job is already running.")
+		return
+	}
+
+	hashTypeStr := form.GetFormItemByLabel("Hash Type").(*tview.InputField).GetText()
+	hashType, _, err := lookupHashType(hashTypeStr, t.hashTypeOptions, t.pinnedHashTypes())
+	if err != nil {
+		t.logError("Invalid hash type", err)
+		return
+	}
+	_, attackMode := form.GetFormItemByLabel("Attack Mode").(*tview.DropDown).GetCurrentOption()
+	var attack crackerjack.MaskAttack
+	if crackerjack.UsesMask(attackMode) {
+		attack, err = maskAttack(
+			form.GetFormItemByLabel("Mask").(*tview.InputField).GetText(),
+			strings.TrimSpace(form.GetFormItemByLabel("Mask File").(*tview.InputField).GetText()),
+			mask.SplitCharsets(form.GetFormItemByLabel("Charsets").(*tview.InputField).GetText()),
+			form.GetFormItemByLabel("Increment").(*tview.InputField).GetText(),
+		)
+		if err != nil {
+			t.logError("Error in mask settings", err)
+			return
+		}
+	}
+
+	sessionID := t.sessionID
+	sessionName := form.GetFormItemByLabel("Session Name").(*tview.InputField).GetText()
+	hashes := form.GetFormItemByLabel("Hashes").(*tview.TextArea).GetText()
+	usernames := form.GetFormItemByLabel("Hashes Contain Usernames").(*tview.Checkbox).IsChecked()
+	var wordlist, wordlistFile, rule string
+	scope := crackerjack.WordlistGlobal
+	if crackerjack.UsesWordlist(attackMode) {
+		var index int
+		index, wordlist = form.GetFormItemByLabel("Wordlist").(*tview.DropDown).GetCurrentOption()
+		if i := index - len(t.wordlistOptions); i >= 0 && i < len(t.sessionWordlists) {
+			wordlist, wordlistFile = t.sessionWordlists[i].name, t.sessionWordlists[i].file
+			scope = crackerjack.WordlistSession
+		}
+	}
+	if attackMode == crackerjack.ModeWordlist {
+		_, rule = form.GetFormItemByLabel("Rules").(*tview.DropDown).GetCurrentOption()
+	}
+	var combinator crackerjack.Combinator
+	if attackMode == crackerjack.ModeCombinator {
+		_, left := form.GetFormItemByLabel("Left Wordlist").(*tview.DropDown).GetCurrentOption()
+		_, right := form.GetFormItemByLabel("Right Wordlist").(*tview.DropDown).GetCurrentOption()
+		combinator = crackerjack.Combinator{
+			LeftWordlist:  left,
+			RightWordlist: right,
+			LeftRule:      strings.TrimSpace(form.GetFormItemByLabel("Left Rule").(*tview.InputField).GetText()),
+			RightRule:     strings.TrimSpace(form.GetFormItemByLabel("Right Rule").(*tview.InputField).GetText()),
+		}
+	}
+
+	t.isJobRunning = true
+	t.log("[yellow]Starting/Updating job...")
+
+	// logUI and fail report from the job goroutine.
+	logUI := func(msg string) {
+		t.app.QueueUpdateDraw(func() {
+			t.log(msg)
+		})
+	}
+	fail := func(prefix string, err error) {
+		t.app.QueueUpdateDraw(func() {
+			t.logError(prefix, err)
+		})
+		t.isJobRunning = false
+	}
+
+	go func() {
+		var err error
+		if sessionID == 0 {
+			sessionID, err = t.client.CreateSession(t.ctx, sessionName)
+			if err != nil {
+				fail("Error creating session", err)
+				return
+			}
+			t.app.QueueUpdateDraw(func() {
+				t.sessionID = sessionID
+				t.log(fmt.Sprintf("[green]New session created with ID: %d", sessionID))
+			})
+		} else {
+			logUI(fmt.Sprintf("Updating existing session with ID: %d", sessionID))
+		}
+
+		if strings.TrimSpace(hashes) != "" {
+			normalizer := hashid.NewNormalizer(strings.NewReader(hashes), hashType, usernames)
+			normalized, _ := io.ReadAll(normalizer)
+			logUI(fmt.Sprintf("Submitting %s.", describeNormalized(normalizer.Stats(), usernames)))
+			total := int64(len(normalized))
+			_, err := t.client.UploadHashesFrom(t.ctx, sessionID, bytes.NewReader(normalized), crackerjack.HashUpload{
+				ContainsUsernames: usernames,
+				Progress: func(acked int64) {
+					t.app.QueueUpdateDraw(func() {
+						progress.SetText("Uploading hashes: " + uploadProgress(acked, total))
+					})
+				},
+			})
+			if err != nil {
+				fail("Error uploading hashes", err)
+				return
+			}
+			t.app.QueueUpdateDraw(func() {
+				t.usernames = usernames
+				t.log("Hashes uploaded.")
+			})
+		} else {
+			logUI("No new hashes provided, keeping existing ones.")
+		}
+
+		if err := t.client.SetHashType(t.ctx, sessionID, hashType); err != nil {
+			fail("Error setting hash type", err)
+			return
+		}
+		recent, err := rememberHashType(hashType)
+		t.app.QueueUpdateDraw(func() {
+			t.log("Hash type set.")
+			if err != nil {
+				t.logError("Error saving recent hash types", err)
+				return
+			}
+			t.recentHashTypes = recent
+		})
+
+		if err := t.client.SetMode(t.ctx, sessionID, attackMode); err != nil {
+			fail("Error setting mode", err)
+			return
+		}
+		logUI(fmt.Sprintf("Mode set to %s.", attackMode))
+
+		if wordlistFile != "" {
+			_, err := uploadLocalFile(t.ctx, wordlistFile, wordlist, func(ctx context.Context, name string, r io.Reader) error {
+				return t.client.UploadSessionWordlist(ctx, sessionID, name, r)
+			})
+			if err != nil {
+				fail("Error uploading wordlist", err)
+				return
+			}
+			t.app.QueueUpdateDraw(func() {
+				for i := range t.sessionWordlists {
+					if t.sessionWordlists[i].name == wordlist {
+						t.sessionWordlists[i].file = ""
+					}
+				}
+				t.log(fmt.Sprintf("Wordlist %s uploaded to the session.", tview.Escape(wordlist)))
+			})
+		}
+		if crackerjack.UsesWordlist(attackMode) {
+			if err := t.client.SetWordlist(t.ctx, sessionID, wordlist, scope); err != nil {
+				fail("Error setting wordlist", err)
+				return
+			}
+			logUI("Wordlist set.")
+		}
+		if attackMode == crackerjack.ModeCombinator {
+			if err := t.client.SetCombinator(t.ctx, sessionID, combinator); err != nil {
+				fail("Error setting combinator wordlists", err)
+				return
+			}
+			logUI("Combinator wordlists set.")
+		}
+		if attackMode == crackerjack.ModeWordlist && rule != "None" {
+			if err := t.client.SetRule(t.ctx, sessionID, rule); err != nil {
+				fail("Error setting rule", err)
+				return
+			}
+			logUI("Rule set.")
+		}
+		if crackerjack.UsesMask(attackMode) {
+			if err := t.client.SetMaskAttack(t.ctx, sessionID, attack); err != nil {
+				fail("Error setting mask", err)
+				return
+			}
+			if len(attack.Masks) > 0 {
+				logUI(fmt.Sprintf("Mask list set (%d masks).", len(attack.Masks)))
+			} else {
+				logUI("Mask set.")
+			}
+		}
+
+		if err := t.client.StartJob(t.ctx, sessionID); err != nil {
+			fail("Error starting job", err)
+			return
+		}
+		logUI("[green]Job started successfully! Polling for status...")
+
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if !t.isJobRunning {
+				return
+			}
+			state, err := t.client.GetState(t.ctx, sessionID)
+			if err != nil {
+				fail("Error polling status", err)
+				return
+			}
+
+			t.app.QueueUpdateDraw(func() {
+				t.log(fmt.Sprintf("Polling: Status='%s', Progress=%.2f%%", state.Description, state.Progress))
+				progress.SetText(tview.Escape(stateSummary(state)))
+			})
+
+			if state.State == 2 || state.State == 3 || state.State == 5 {
+				logUI("[green]Job finished. Fetching results...")
+				resultsStr, err := t.client.DownloadResults(t.ctx, sessionID, crackerjack.ResultsCracked)
+				t.app.QueueUpdateDraw(func() {
+					if err != nil {
+						t.logError("Error fetching results", err)
+					} else {
+						t.displayResults(results, resultsStr, t.usernames)
+					}
+					t.isJobRunning = false
+				})
+				return
+			}
+		}
+	}()
+}
+
+// displayResults fills the results table from "hash:plain" lines, or from
+// "username:hash:plain" lines when the hashes were uploaded with usernames.
+func (t *TUIApp) displayResults(table *tview.Table, resultsStr string, withUsernames bool) {
+	table.Clear()
+	headers := []string{"Hash", "Plaintext"}
+	if withUsernames {
+		headers = []string{"Username", "Hash", "Plaintext"}
+	}
+	for i, h := range headers {
+		table.SetCell(0, i, tview.NewTableCell(h).SetSelectable(false).SetTextColor(tview.Styles.SecondaryTextColor))
+	}
+
+	lines := strings.Split(resultsStr, "\n")
+
+	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
+		t.log("No cracked passwords found for this session.")
+		return
+	}
+
+	rowCount := 1
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		col := 0
+		var username string
+		if withUsernames {
+			var found bool
+			if username, line, found = strings.Cut(line, ":"); !found {
+				continue
+			}
+			col = 1
+		}
+		// The plaintext follows the last colon, since hashes such as
+		// NetNTLM and hash:salt contain colons themselves.
+		i := strings.LastIndexByte(line, ':')
+		if i < 0 {
+			continue
+		}
+		hash, plaintext := line[:i], line[i+1:]
+		if withUsernames {
+			table.SetCell(rowCount, 0, tview.NewTableCell(username).SetTextColor(tview.Styles.SecondaryTextColor))
+		}
+		table.SetCell(rowCount, col, tview.NewTableCell(hash).SetTextColor(tview.Styles.PrimaryTextColor))
+		table.SetCell(rowCount, col+1, tview.NewTableCell(plaintext).SetTextColor(tview.Styles.TertiaryTextColor))
+		rowCount++
+	}
+	t.log(fmt.Sprintf("Displayed %d results.", rowCount-1))
+}
+
+// =================================================================================
+// 4. CLI (Command-Line Interface)
+// =================================================================================
+
+// runCLI runs a job from the flags and returns the exit status. It returns
+// rather than exiting so that its deferred cleanup, such as removing the
+// temporary file of imported hashes, always runs.
+func runCLI(ctx context.Context, client crackerjack.API, profile *Profile, args *cliArgs) int {
 	fmt.Println("Running in CLI mode...")
+	fmt.Printf("Route: %s\n", client.Route())
+
+	var hashes io.ReadSeeker
+	var total int64
+	var sample string // the start of the hashes, for -hash-type auto
+	if args.hashesFile != "" {
+		f, err := os.Open(args.hashesFile)
+		if err != nil {
+			fmt.Printf("Error reading hashes file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		if info, err := f.Stat(); err == nil {
+			total = info.Size()
+		}
+		hashes = f
+		if args.hashType == hashTypeAuto {
+			buf := make([]byte, hashSampleBytes)
+			n, _ := f.ReadAt(buf, 0)
+			sample = string(buf[:n])
+			if int64(n) < total {
+				// Drop the last line, which may be cut off.
+				sample = sample[:strings.LastIndex(sample, "\n")+1]
+			}
+		}
+	} else {
+		hashes = strings.NewReader(args.hashes)
+		total = int64(len(args.hashes))
+		sample = args.hashes
+	}
+
+	if args.hashType == hashTypeAuto && args.hashesFormat != "" {
+		args.hashType = "" // the importer picks the hash type
+	}
+	if _, err := strconv.Atoi(args.hashType); err != nil && args.hashType != "" && args.hashType != hashTypeAuto {
+		pinned := pinnedHashTypes(profile.FavoriteHashTypes, loadState().RecentHashTypes)
+		hashType, err := searchHashType(ctx, client, args.hashType, pinned)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		args.hashType = hashType
+	}
+
+	if args.hashesFormat != "" {
+		imported, mode, err := importHashes(args.hashesFormat, hashes, args.hashType)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		defer os.Remove(imported.Name())
+		defer imported.Close()
+		if info, err := imported.Stat(); err == nil {
+			total = info.Size()
+		}
+		hashes = imported
+		args.hashType = mode
+		args.usernames = true
+	} else if args.hashType == hashTypeAuto {
+		hashType, err := autoHashType(ctx, client, sample, args.usernames)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		args.hashType = hashType
+	}
+
+	var dropped []hashid.Invalid
+	if hashid.CanValidate(args.hashType) {
+		invalid, lines, err := hashid.ValidateLines(args.hashType, hashes, args.usernames)
+		if _, seekErr := hashes.Seek(0, io.SeekStart); err == nil {
+			err = seekErr
+		}
+		if err != nil {
+			fmt.Printf("Error validating hashes: %v\n", err)
+			return 1
+		}
+		if len(invalid) > 0 {
+			fmt.Printf("%d of %d lines are not valid hashes of type %s:\n", len(invalid), lines, args.hashType)
+			for _, inv := range invalid[:min(maxReportedInvalid, len(invalid))] {
+				fmt.Printf("  %v\n", inv)
+			}
+			if len(invalid) > maxReportedInvalid {
+				fmt.Printf("  ... and %d more\n", len(invalid)-maxReportedInvalid)
+			}
+			if len(invalid) == lines {
+				fmt.Println("Error: none of the hashes are valid. Check -hash-type and -contains-usernames.")
+				return 1
+			}
+			drop := args.dropInvalid
+			if !drop {
+				var answer string
+				fmt.Print("Drop them before uploading? [y/N]: ")
+				fmt.Scanln(&answer)
+				drop = strings.EqualFold(strings.TrimSpace(answer), "y")
+			}
+			if drop {
+				dropped = invalid
+				fmt.Printf("Dropping %d invalid lines.\n", len(invalid))
+			} else {
+				fmt.Println("Uploading all lines.")
+			}
+		}
+	}
+
+	// normalized reads the hashes from the start, without the dropped lines.
+	// They are read twice: to count them and the upload size, then to upload.
+	normalized := func() (*hashid.Normalizer, error) {
+		if _, err := hashes.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		r := io.Reader(hashes)
+		if len(dropped) > 0 {
+			r = withoutLines(hashes, dropped)
+		}
+		return hashid.NewNormalizer(r, args.hashType, args.usernames), nil
+	}
+	counted, err := normalized()
+	if err == nil {
+		total, err = io.Copy(io.Discard, counted)
+	}
+	var upload io.Reader
+	if err == nil {
+		upload, err = normalized()
+	}
+	if err != nil {
+		fmt.Printf("Error reading hashes: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Submitting %s.\n", describeNormalized(counted.Stats(), args.usernames))
+
+	var sessionID int
+	var offset int64
+	if args.resumeSession != 0 {
+		sessionID = args.resumeSession
+		offset, err = client.UploadedHashBytes(ctx, sessionID)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Resuming the hash upload of session %d after %s.\n", sessionID, formatBytes(offset))
+	} else {
+		fmt.Printf("Creating session '%s'...\n", args.sessionName)
+		sessionID, err = client.CreateSession(ctx, args.sessionName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Session created with ID: %d\n", sessionID)
+	}
+
+	acked, err := client.UploadHashesFrom(ctx, sessionID, upload, crackerjack.HashUpload{
+		ContainsUsernames: args.usernames,
+		Gzip:              args.gzip,
+		Offset:            offset,
+		Progress: func(acked int64) {
+			fmt.Printf("\rUploading hashes: %s", uploadProgress(acked, total))
+		},
+	})
+	fmt.Println()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		fmt.Printf("%s of hashes were uploaded. Resume with -resume-session %d.\n", formatBytes(acked), sessionID)
+		return 1
+	}
+	fmt.Println("Hashes uploaded.")
+
+	if err := client.SetHashType(ctx, sessionID, args.hashType); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Println("Hash type set.")
+	if _, err := rememberHashType(args.hashType); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save recent hash types: %v\n", err)
+	}
+
+	if err := client.SetMode(ctx, sessionID, args.mode); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Mode set to %s.\n", args.mode)
+
+	if crackerjack.UsesWordlist(args.mode) {
+		wordlist, scope := args.wordlist, crackerjack.WordlistGlobal
+		if args.wordlistFile != "" {
+			wordlist, err = uploadLocalFile(ctx, args.wordlistFile, "", func(ctx context.Context, name string, r io.Reader) error {
+				return client.UploadSessionWordlist(ctx, sessionID, name, r)
+			})
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return 1
+			}
+			scope = crackerjack.WordlistSession
+			fmt.Printf("Wordlist %s uploaded to the session.\n", wordlist)
+		}
+		if err := client.SetWordlist(ctx, sessionID, wordlist, scope); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		fmt.Println("Wordlist set.")
+	}
+	if args.mode == crackerjack.ModeCombinator {
+		combinator := crackerjack.Combinator{
+			LeftWordlist:  args.leftWordlist,
+			RightWordlist: args.rightWordlist,
+			LeftRule:      args.leftRule,
+			RightRule:     args.rightRule,
+		}
+		if err := client.SetCombinator(ctx, sessionID, combinator); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		fmt.Println("Combinator wordlists set.")
+	}
+	if args.mode == crackerjack.ModeWordlist {
+		rule := args.rule
+		if args.ruleFile != "" {
+			rule, err = uploadLocalFile(ctx, args.ruleFile, "", client.UploadRule)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return 1
+			}
+			fmt.Printf("Rule %s uploaded.\n", rule)
+		}
+		if rule != "" {
+			if err := client.SetRule(ctx, sessionID, rule); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return 1
+			}
+			fmt.Println("Rule set.")
+		}
+	}
+	if crackerjack.UsesMask(args.mode) {
+		increment := ""
+		if args.increment {
+			increment = formatIncrement(args.incrementMin, args.incrementMax)
+		}
+		attack, err := maskAttack(args.mask, args.maskFile, args.charsets[:], increment)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		if err := client.SetMaskAttack(ctx, sessionID, attack); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		if len(attack.Masks) > 0 {
+			fmt.Printf("Mask list set (%d masks).\n", len(attack.Masks))
+		} else {
+			fmt.Println("Mask set.")
+		}
+	}
+
+	if err := client.StartJob(ctx, sessionID); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 1
+	}
+	fmt.Println("Job started! Polling for status...")
+
+	for {
+		state, err := client.GetState(ctx, sessionID)
+		if ctx.Err() != nil {
+			fmt.Printf("\nInterrupted. The job keeps running on the server (session %d).\n", sessionID)
+			return 130
+		}
+		if err != nil {
+			fmt.Printf("Error polling status: %v\n", err)
+			return 1
+		}
+		fmt.Printf("\rStatus: %s", stateSummary(state))
+
+		if state.State == 2 || state.State == 3 || state.State == 5 {
+			fmt.Println("\nJob finished.")
+			results, err := client.DownloadResults(ctx, sessionID, crackerjack.ResultsCracked)
+			if err != nil {
+				fmt.Printf("Error fetching results: %v\n", err)
+			} else {
+				fmt.Println("\n--- Cracked Passwords ---")
+				fmt.Println(results)
+			}
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return 0
+}
+
+// hashTypeAuto is the -hash-type value that detects the hash type from the
+// hashes. hashSampleBytes is how much of a hashes file is read to detect it.
+const (
+	hashTypeAuto    = "auto"
+	hashSampleBytes = 256 << 10
+)
+
+// autoHashType detects the hash type of the sampled hashes for -hash-type
+// auto, printing the ranked candidates the server supports.
+func autoHashType(ctx context.Context, client crackerjack.API, sample string, usernames bool) (string, error) {
+	hashTypes, err := client.GetHashTypes(ctx)
+	if err != nil {
+		return "", err
+	}
+	matches, lines := detectHashTypes(sample, usernames, hashTypeOptions(hashTypes))
+	if len(matches) == 0 {
+		return "", errors.New("could not detect the hash type: pass a mode with -hash-type")
+	}
+	fmt.Println("Detected hash types:")
+	for _, match := range matches[:min(5, len(matches))] {
+		fmt.Printf("  %s\t%d/%d lines\n", match.option, match.lines, lines)
+	}
+	best := matches[0]
+	if len(matches) > 1 && matches[1].lines == best.lines {
+		fmt.Printf("The format is ambiguous; using %s. Pass -hash-type to choose another.\n", best.option)
+	}
+	if best.lines < lines {
+		fmt.Printf("Warning: %d of %d sampled lines do not look like %s.\n", lines-best.lines, lines, best.option)
+	}
+	hashType, _ := hashTypeOf(best.option)
+	return hashType, nil
+}
+
+// importHashes converts hashes in a dump format (see importer.Formats) into
+// "username:hash" lines of a single mode in a temporary file, which the
+// caller must remove. Without a mode, the most common one in the dump is
+// used.
+func importHashes(formatName string, r io.ReadSeeker, mode string) (*os.File, string, error) {
+	format, err := importer.Lookup(formatName)
+	if err != nil {
+		return nil, "", err
+	}
+	summary, err := format.Scan(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if mode == "" {
+		mode = summary.Mode()
+	}
+	if summary.Modes[mode] == 0 {
+		if mode == "" {
+			return nil, "", fmt.Errorf("no %s hashes found", format.Name)
+		}
+		return nil, "", fmt.Errorf("no %s hashes of type %s found", format.Name, mode)
+	}
+
+	fmt.Printf("Found %d hashes of type %s in %s format.\n", summary.Modes[mode], mode, format.Name)
+	if other := summary.Entries() - summary.Modes[mode]; other > 0 {
+		fmt.Printf("Left out %d hashes of other types (pass -hash-type to pick one).\n", other)
+	}
+	if summary.Skipped > 0 {
+		fmt.Printf("Skipped %d lines without a usable hash.\n", summary.Skipped)
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+	tmp, err := os.CreateTemp("", "cracker-client-hashes-*.txt")
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err = format.Convert(r, tmp, mode); err == nil {
+		_, err = tmp.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, "", err
+	}
+	return tmp, mode, nil
+}
+
+// describeNormalized summarises how many hashes are left after
+// normalisation, e.g. "950 unique hashes of 1000 provided".
+func describeNormalized(stats hashid.NormalizeStats, usernames bool) string {
+	text := fmt.Sprintf("%d unique hashes of %d provided", stats.Unique, stats.Provided)
+	if usernames && stats.Lines != stats.Unique {
+		text += fmt.Sprintf(" (%d username:hash lines)", stats.Lines)
+	}
+	return text
+}
+
+// maxReportedInvalid caps how many malformed hashes are listed.
+const maxReportedInvalid = 20
+
+// withoutLines returns a reader over r that skips the invalid lines.
+func withoutLines(r io.Reader, invalid []hashid.Invalid) io.Reader {
+	drop := make(map[int]bool, len(invalid))
+	for _, inv := range invalid {
+		drop[inv.Line] = true
+	}
+	return &lineFilter{r: bufio.NewReader(r), drop: drop}
+}
+
+// lineFilter is a reader that drops lines by their 1-based number.
+type lineFilter struct {
+	r       *bufio.Reader
+	drop    map[int]bool
+	line    int
+	pending []byte // the rest of the current line
+	err     error
+}
+
+func (f *lineFilter) Read(p []byte) (int, error) {
+	for len(f.pending) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		line, err := f.r.ReadBytes('\n')
+		f.line++
+		if !f.drop[f.line] {
+			f.pending = line
+		}
+		f.err = err
+	}
+	n := copy(p, f.pending)
+	f.pending = f.pending[n:]
+	return n, nil
+}
+
+// searchHashType resolves a -hash-type name such as "ntlm" to a mode using
+// the server's hash types.
+func searchHashType(ctx context.Context, client crackerjack.API, query string, pinned []string) (string, error) {
+	hashTypes, err := client.GetHashTypes(ctx)
+	if err != nil {
+		return "", err
+	}
+	mode, option, err := lookupHashType(query, hashTypeOptions(hashTypes), pinned)
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("Hash type: %s\n", option)
+	return mode, nil
+}
+
+// runConfigCommand implements the "config" subcommand family, which manages
+// config.json without the interactive first-run prompt.
+func runConfigCommand(args []string) {
+	fs := flag.NewFlagSet("config", flag.ExitOnError)
+	profileName := fs.String("profile", "", "Profile to operate on (defaults to the configured default profile).")
+	force := fs.Bool("force", false, "Do not ask for confirmation (reset only).")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cracker-client config <command> [flags] [args]")
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Commands:")
+		fmt.Fprintln(fs.Output(), "  show                 Print the configuration with API keys redacted.")
+		fmt.Fprintln(fs.Output(), "  set <key> <value>    Set url, apikey, apikey-file, apikey-command, proxy, ca-file,")
+		fmt.Fprintln(fs.Output(), "                       cert-file, key-file, pin, insecure, timeout-poll,")
+		fmt.Fprintln(fs.Output(), "                       timeout-control, timeout-transfer, retry-attempts,")
+		fmt.Fprintln(fs.Output(), "                       retry-base-delay, retry-max-delay, favorite-hash-types (comma-")
+		fmt.Fprintln(fs.Output(), "                       separated modes) or default (the default profile name).")
+		fmt.Fprintln(fs.Output(), "                       An empty value clears an optional setting.")
+		fmt.Fprintln(fs.Output(), "  encrypt-apikey       Prompt for the API key and a passphrase, and store the key encrypted.")
+		fmt.Fprintln(fs.Output(), "  test                 Validate the profile and test the connection to the server.")
+		fmt.Fprintln(fs.Output(), "  path                 Print the path of the configuration file.")
+		fmt.Fprintln(fs.Output(), "  reset                Delete the configuration file.")
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+
+	switch command {
+	case "path":
+		fmt.Println(configFile)
+
+	case "show":
+		config, err := readConfig()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		names := config.ProfileNames()
+		if *profileName != "" {
+			names = []string{*profileName}
+		}
+		fmt.Printf("Config file: %s\n", configFile)
+		fmt.Printf("Default profile: %s\n", config.DefaultProfile)
+		for _, name := range names {
+			profile, err := config.Profile(name)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("\n[%s]\n", name)
+			fmt.Printf("  url:    %s\n", profile.URL)
+			fmt.Printf("  apikey: %s\n", profile.apiKeySource())
+			if profile.Proxy != "" {
+				proxy := profile.Proxy
+				if u, err := url.Parse(proxy); err == nil {
+					proxy = u.Redacted()
+				}
+				fmt.Printf("  proxy:  %s\n", proxy)
+			}
+			if timeouts := profile.Timeouts; timeouts != nil {
+				fmt.Printf("  timeouts: poll=%s control=%s transfer=%s\n",
+					timeouts.Poll.Or(crackerjack.DefaultPollTimeout), timeouts.Control.Or(crackerjack.DefaultControlTimeout), timeouts.Transfer.Or(crackerjack.DefaultTransferTimeout))
+			}
+			if retry := profile.Retry; retry != nil {
+				attempts := retry.MaxAttempts
+				if attempts <= 0 {
+					attempts = crackerjack.DefaultRetryAttempts
+				}
+				fmt.Printf("  retry:  attempts=%d base-delay=%s max-delay=%s\n",
+					attempts, retry.BaseDelay.Or(crackerjack.DefaultRetryBaseDelay), retry.MaxDelay.Or(crackerjack.DefaultRetryMaxDelay))
+			}
+			if len(profile.FavoriteHashTypes) > 0 {
+				fmt.Printf("  favorite hash types: %s\n", strings.Join(profile.FavoriteHashTypes, ", "))
+			}
+			if tlsSettings := profile.TLS; tlsSettings != nil {
+				fmt.Printf("  tls:    ca-file=%q cert-file=%q key-file=%q pin=%q insecure=%t\n",
+					tlsSettings.CAFile, tlsSettings.CertFile, tlsSettings.KeyFile, tlsSettings.PinSHA256, tlsSettings.InsecureSkipVerify)
+			}
+		}
+
+	case "set":
+		if fs.NArg() != 2 {
+			fmt.Println("Error: config set requires a key and a value.")
+			fs.Usage()
+			os.Exit(2)
+		}
+		key, value := fs.Arg(0), strings.TrimSpace(fs.Arg(1))
+
+		config, err := readConfig()
+		if errors.Is(err, os.ErrNotExist) {
+			config, err = &Config{}, nil
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if config.Profiles == nil {
+			config.Profiles = make(map[string]*Profile)
+		}
+
+		if key == "default" {
+			if _, err := config.Profile(value); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			config.DefaultProfile = value
+		} else {
+			name := *profileName
+			if name == "" {
+				name = config.DefaultProfile
+			}
+			if name == "" {
+				name = defaultProfileName
+			}
+			profile, ok := config.Profiles[name]
+			if !ok {
+				profile = &Profile{}
+				config.Profiles[name] = profile
+			}
+			if config.DefaultProfile == "" {
+				config.DefaultProfile = name
+			}
+
+			switch key {
+			case "url":
+				if err := validateServerURL(value); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				profile.URL = strings.TrimSuffix(value, "/")
+			case "apikey":
+				profile.clearAPIKey()
+				profile.APIKey = value
+			case "apikey-file":
+				profile.clearAPIKey()
+				profile.APIKeyFile = value
+			case "apikey-command":
+				profile.clearAPIKey()
+				profile.APIKeyCommand = value
+			case "proxy":
+				if value != "" {
+					if _, err := crackerjack.ParseProxyURL(value); err != nil {
+						fmt.Printf("Error: %v\n", err)
+						os.Exit(1)
+					}
+				}
+				profile.Proxy = value
+			case "timeout-poll", "timeout-control", "timeout-transfer":
+				var d time.Duration
+				if value != "" {
+					if d, err = time.ParseDuration(value); err != nil {
+						fmt.Printf("Error: invalid duration %q: %v\n", value, err)
+						os.Exit(1)
+					}
+				}
+				if profile.Timeouts == nil {
+					profile.Timeouts = &crackerjack.Timeouts{}
+				}
+				switch key {
+				case "timeout-poll":
+					profile.Timeouts.Poll = crackerjack.Duration(d)
+				case "timeout-control":
+					profile.Timeouts.Control = crackerjack.Duration(d)
+				case "timeout-transfer":
+					profile.Timeouts.Transfer = crackerjack.Duration(d)
+				}
+				if *profile.Timeouts == (crackerjack.Timeouts{}) {
+					profile.Timeouts = nil
+				}
+			case "retry-attempts", "retry-base-delay", "retry-max-delay":
+				if profile.Retry == nil {
+					profile.Retry = &crackerjack.RetryPolicy{}
+				}
+				if err := setRetryOption(profile.Retry, key, value); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				if *profile.Retry == (crackerjack.RetryPolicy{}) {
+					profile.Retry = nil
+				}
+			case "favorite-hash-types":
+				var modes []string
+				for _, mode := range strings.Split(value, ",") {
+					if mode = strings.TrimSpace(mode); mode == "" {
+						continue
+					}
+					if _, err := strconv.Atoi(mode); err != nil {
+						fmt.Printf("Error: favorite-hash-types must be hashcat mode numbers, got %q\n", mode)
+						os.Exit(1)
+					}
+					modes = append(modes, mode)
+				}
+				profile.FavoriteHashTypes = modes
+			case "ca-file", "cert-file", "key-file", "pin", "insecure":
+				if profile.TLS == nil {
+					profile.TLS = &crackerjack.TLSConfig{}
+				}
+				if err := setTLSOption(profile.TLS, key, value); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
+				if *profile.TLS == (crackerjack.TLSConfig{}) {
+					profile.TLS = nil
+				}
+			default:
+				fmt.Printf("Error: unknown config key %q.\n", key)
+				fs.Usage()
+				os.Exit(2)
+			}
+		}
 
-	fmt.Printf("Creating session '%s'...\n", args.sessionName)
-	sessionID, err := client.CreateSession(args.sessionName)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		if err := saveConfig(config); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Updated %s in %s\n", key, configFile)
+
+	case "test":
+		config, err := readConfig()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		profile, err := config.Profile(*profileName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		effective := *profile
+		if err := effective.resolveAPIKey(true); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		profile = &effective
+		if err := profile.Validate(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Connecting to %s...\n", profile.URL)
+		for _, warning := range profile.warnings() {
+			fmt.Fprintln(os.Stderr, warning)
+		}
+		client, err := newClient(profile, nil)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Route: %s\n", client.Route())
+		sessions, err := client.GetAllSessions(context.Background())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("OK: authenticated, %d sessions visible.\n", len(sessions))
+
+	case "encrypt-apikey":
+		config, err := readConfig()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		profile, err := config.Profile(*profileName)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		current := *profile
+		if err := current.resolveAPIKey(true); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		key := current.APIKey
+		if key == "" {
+			if key, err = readSecret("API Key: "); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		passphrase, err := readPassphrase(true, true)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		encrypted, err := encryptAPIKey(key, passphrase)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		profile.clearAPIKey()
+		profile.APIKeyEncrypted = encrypted
+		if err := saveConfig(config); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("API key encrypted in %s. Unlock it with $%s or at the prompt.\n", configFile, envPassphrase)
+
+	case "reset":
+		if _, err := os.Stat(configFile); os.IsNotExist(err) {
+			fmt.Println("Nothing to reset: no configuration file found.")
+			return
+		}
+		if !*force {
+			var answer string
+			fmt.Printf("Delete %s? [y/N]: ", configFile)
+			fmt.Scanln(&answer)
+			if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+				fmt.Println("Aborted.")
+				return
+			}
+		}
+		if err := os.Remove(configFile); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Configuration reset.")
+
+	default:
+		fmt.Printf("Error: unknown config command %q.\n", command)
+		fs.Usage()
+		os.Exit(2)
 	}
-	fmt.Printf("Session created with ID: %d\n", sessionID)
+}
 
-	var hashes string
-	if args.hashesFile != "" {
-		data, err := os.ReadFile(args.hashesFile)
+// runSessionsCommand implements the "sessions" subcommand family, which
+// lists sessions and controls their jobs by session ID.
+func runSessionsCommand(args []string) {
+	fs := flag.NewFlagSet("sessions", flag.ExitOnError)
+	var cli cliArgs
+	cli.addConnectionFlags(fs)
+	nameGlob := fs.String("name", "", "Select sessions whose name matches this glob, e.g. 'CLI Job*' (delete only).")
+	olderThan := fs.String("older-than", "", "Select sessions created longer ago than this, e.g. 72h or 30d (delete only).")
+	yes := fs.Bool("yes", false, "Do not ask for confirmation (delete only).")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cracker-client sessions <command> [flags] [session IDs]")
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Session IDs can be single IDs or ranges such as 10-20.")
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Commands:")
+		fmt.Fprintln(fs.Output(), "  list                 List all sessions and their state.")
+		fmt.Fprintln(fs.Output(), "  stop <id>...         Stop the sessions' jobs.")
+		fmt.Fprintln(fs.Output(), "  pause <id>...        Pause the sessions' jobs, freeing the GPUs.")
+		fmt.Fprintln(fs.Output(), "  resume <id>...       Resume paused jobs.")
+		fmt.Fprintln(fs.Output(), "  restore <id>...      Restart jobs from their last checkpoint.")
+		fmt.Fprintln(fs.Output(), "  rename <id> <name>   Rename a session.")
+		fmt.Fprintln(fs.Output(), "  delete [id]...       Delete the sessions selected by ID, -name and/or -older-than.")
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+
+	client, _ := connectCLI(&cli)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch command {
+	case "list":
+		sessions, err := client.GetAllSessions(ctx)
 		if err != nil {
-			fmt.Printf("Error reading hashes file: %v\n", err)
+			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-		hashes = string(data)
-	} else {
-		hashes = args.hashes
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tUSER\tSTATE\tPROGRESS\tCRACKED")
+		for _, s := range sessions {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%.2f%%\t%d/%d\n", s.ID, s.Name, s.Username,
+				s.Hashcat.StateDescription, s.Hashcat.Progress, s.Hashcat.CrackedPasswords, s.Hashcat.AllPasswords)
+		}
+		w.Flush()
+
+	case crackerjack.ActionStop, crackerjack.ActionPause, crackerjack.ActionResume, crackerjack.ActionRestore:
+		do := jobActions(client)[command]
+		ids, err := parseSessionIDs(fs.Args())
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			fs.Usage()
+			os.Exit(2)
+		}
+		failed := false
+		for _, id := range ids {
+			if err := do(ctx, id); err != nil {
+				fmt.Printf("Error: session %d: %v\n", id, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("Session %d: %s sent.\n", id, command)
+		}
+		if failed {
+			os.Exit(1)
+		}
+
+	case "rename":
+		if fs.NArg() < 2 {
+			fmt.Println("Error: sessions rename requires a session ID and a new name.")
+			fs.Usage()
+			os.Exit(2)
+		}
+		ids, err := parseSessionIDs(fs.Args()[:1])
+		if err != nil || len(ids) != 1 {
+			fmt.Printf("Error: invalid session ID %q\n", fs.Arg(0))
+			os.Exit(2)
+		}
+		name := strings.Join(fs.Args()[1:], " ")
+		if err := client.RenameSession(ctx, ids[0], name); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Session %d renamed to %q.\n", ids[0], name)
+
+	case "delete":
+		var age time.Duration
+		if *olderThan != "" {
+			var err error
+			if age, err = parseAge(*olderThan); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(2)
+			}
+		}
+		sessions, err := client.GetAllSessions(ctx)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		selected, unknownAge, err := selectSessions(sessions, fs.Args(), *nameGlob, age)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			fs.Usage()
+			os.Exit(2)
+		}
+		if unknownAge > 0 {
+			fmt.Printf("Skipped %d sessions with no known creation time.\n", unknownAge)
+		}
+		if len(selected) == 0 {
+			fmt.Println("No sessions match.")
+			return
+		}
+
+		fmt.Printf("The following %d sessions will be deleted:\n", len(selected))
+		for _, s := range selected {
+			fmt.Printf("  %d\t%s\t%s\n", s.ID, s.Name, s.Created)
+		}
+		if !*yes {
+			var answer string
+			fmt.Print("Delete them? [y/N]: ")
+			fmt.Scanln(&answer)
+			if !strings.EqualFold(strings.TrimSpace(answer), "y") {
+				fmt.Println("Aborted.")
+				return
+			}
+		}
+
+		failed := false
+		for _, s := range selected {
+			if err := client.DeleteSession(ctx, s.ID); err != nil {
+				fmt.Printf("Error: session %d: %v\n", s.ID, err)
+				failed = true
+				continue
+			}
+			fmt.Printf("Session %d deleted.\n", s.ID)
+		}
+		if failed {
+			os.Exit(1)
+		}
+
+	default:
+		fmt.Printf("Error: unknown sessions command %q.\n", command)
+		fs.Usage()
+		os.Exit(2)
 	}
+}
 
-	if err := client.UploadHashes(sessionID, hashes); err != nil {
+// runResultsCommand implements the "results" subcommand, which downloads one
+// kind of results for a session to stdout or a file.
+func runResultsCommand(args []string) {
+	fs := flag.NewFlagSet("results", flag.ExitOnError)
+	var cli cliArgs
+	cli.addConnectionFlags(fs)
+	kindName := fs.String("type", string(crackerjack.ResultsCracked), "Results to download: cracked, uncracked, plain or all (potfile).")
+	output := fs.String("o", "", "Write results to this file instead of stdout.")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: cracker-client results [flags] <session ID>")
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	kind, err := crackerjack.ParseResultsKind(*kindName)
+	if err != nil {
 		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(2)
+	}
+	ids, err := parseSessionIDs(fs.Args())
+	if err != nil || len(ids) != 1 {
+		fmt.Println("Error: results requires exactly one session ID.")
+		fs.Usage()
+		os.Exit(2)
 	}
-	fmt.Println("Hashes uploaded.")
 
-	if err := client.SetHashType(sessionID, args.hashType); err != nil {
+	client, _ := connectCLI(&cli)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	results, err := client.DownloadResults(ctx, ids[0], kind)
+	if err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Println("Hash type set.")
-
-	if err := client.SetMode(sessionID, args.mode); err != nil {
-		fmt.Printf("Error: %v\n", err)
+	if *output == "" {
+		fmt.Print(results)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(results), 0600); err != nil {
+		fmt.Printf("Error writing results: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Mode set to %s.\n", args.mode)
+	fmt.Fprintf(os.Stderr, "Wrote %d %s results to %s.\n", countLines(results), kind, *output)
+}
 
-	if args.mode == "wordlist" {
-		if err := client.SetWordlist(sessionID, args.wordlist); err != nil {
+// runFilesCommand implements the "wordlists" and "rules" subcommand
+// families, which list the server's files of that kind and upload local ones.
+func runFilesCommand(kind string, args []string) {
+	fs := flag.NewFlagSet(kind, flag.ExitOnError)
+	var cli cliArgs
+	cli.addConnectionFlags(fs)
+	name := fs.String("name", "", "Name to store the file under (upload only; default: the local file name).")
+	var sessionID *int
+	if kind == "wordlists" {
+		sessionID = fs.Int("session", 0, "Upload to this session only instead of the shared library (upload only).")
+	}
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: cracker-client %s <command> [flags]\n", kind)
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Commands:")
+		fmt.Fprintf(fs.Output(), "  list             List the %s on the server.\n", kind)
+		fmt.Fprintln(fs.Output(), "  upload <file>    Upload a local file.")
+		fmt.Fprintln(fs.Output(), "")
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	command := args[0]
+	fs.Parse(args[1:])
+	if command == "upload" && fs.NArg() != 1 {
+		fmt.Println("Error: upload requires exactly one file.")
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	client, _ := connectCLI(&cli)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch command {
+	case "list":
+		list := client.GetWordlists
+		if kind == "rules" {
+			list = client.GetRules
+		}
+		files, err := list(ctx)
+		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println("Wordlist set.")
-		if args.rule != "" {
-			if err := client.SetRule(sessionID, args.rule); err != nil {
-				fmt.Printf("Error: %v\n", err)
-				os.Exit(1)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSIZE")
+		for _, f := range files {
+			fmt.Fprintf(w, "%s\t%d\n", f.Name, f.Size)
+		}
+		w.Flush()
+
+	case "upload":
+		upload := client.UploadWordlist
+		target := "the shared library"
+		switch {
+		case kind == "rules":
+			upload = client.UploadRule
+		case *sessionID != 0:
+			id := *sessionID
+			upload = func(ctx context.Context, name string, r io.Reader) error {
+				return client.UploadSessionWordlist(ctx, id, name, r)
 			}
-			fmt.Println("Rule set.")
+			target = fmt.Sprintf("session %d", id)
 		}
-	} else { // mask
-		if err := client.SetMask(sessionID, args.mask); err != nil {
+		stored, err := uploadLocalFile(ctx, fs.Arg(0), *name, upload)
+		if err != nil {
 			fmt.Printf("Error: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Println("Mask set.")
+		fmt.Printf("Uploaded %s to %s as %s.\n", fs.Arg(0), target, stored)
+
+	default:
+		fmt.Printf("Error: unknown %s command %q.\n", kind, command)
+		fs.Usage()
+		os.Exit(2)
 	}
+}
 
-	if err := client.StartJob(sessionID); err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+// maskAttack builds the mask settings of a job from a mask or a .hcmask file,
+// custom charsets ?1 to ?4 and an increment range (see parseIncrement).
+func maskAttack(mask, maskFile string, charsets []string, increment string) (crackerjack.MaskAttack, error) {
+	attack := crackerjack.MaskAttack{Mask: mask}
+	if maskFile != "" {
+		masks, err := readMaskFile(maskFile)
+		if err != nil {
+			return attack, err
+		}
+		attack.Masks = masks
+	} else if strings.TrimSpace(mask) == "" {
+		return attack, errors.New("a mask or mask file is required")
 	}
-	fmt.Println("Job started! Polling for status...")
 
-	for {
-		state, err := client.GetState(sessionID)
+	if len(charsets) > 4 {
+		return attack, fmt.Errorf("at most 4 custom charsets can be defined, got %d", len(charsets))
+	}
+	for len(charsets) > 0 && charsets[len(charsets)-1] == "" {
+		charsets = charsets[:len(charsets)-1]
+	}
+	attack.Charsets = charsets
+
+	if increment != "" {
+		minLen, maxLen, err := parseIncrement(increment)
 		if err != nil {
-			fmt.Printf("Error polling status: %v\n", err)
-			os.Exit(1)
+			return attack, err
 		}
-		fmt.Printf("\rStatus: %s - %.2f%%", state.Description, state.Progress)
+		attack.Increment, attack.IncrementMin, attack.IncrementMax = true, minLen, maxLen
+	}
+	return attack, nil
+}
 
-		if state.State == 2 || state.State == 3 || state.State == 5 {
-			fmt.Println("\nJob finished.")
-			results, err := client.DownloadResults(sessionID)
-			if err != nil {
-				fmt.Printf("Error fetching results: %v\n", err)
-			} else {
-				fmt.Println("\n--- Cracked Passwords ---")
-				fmt.Println(results)
+// readMaskFile reads the masks of a .hcmask file, skipping blank lines and
+// comments.
+func readMaskFile(file string) ([]string, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var masks []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		masks = append(masks, line)
+	}
+	if len(masks) == 0 {
+		return nil, fmt.Errorf("%s contains no masks", file)
+	}
+	return masks, nil
+}
+
+// parseIncrement parses an increment range "min-max". Either bound may be
+// left out ("4-", "-8", or "-" for hashcat's defaults).
+func parseIncrement(value string) (minLen, maxLen int, err error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(value), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid increment %q: use min-max, e.g. 1-8", value)
+	}
+	if from != "" {
+		if minLen, err = strconv.Atoi(from); err != nil || minLen < 1 {
+			return 0, 0, fmt.Errorf("invalid increment minimum %q", from)
+		}
+	}
+	if to != "" {
+		if maxLen, err = strconv.Atoi(to); err != nil || maxLen < 1 {
+			return 0, 0, fmt.Errorf("invalid increment maximum %q", to)
+		}
+	}
+	if minLen > 0 && maxLen > 0 && minLen > maxLen {
+		return 0, 0, fmt.Errorf("increment minimum %d is greater than maximum %d", minLen, maxLen)
+	}
+	return minLen, maxLen, nil
+}
+
+// formatIncrement reverses parseIncrement.
+func formatIncrement(minLen, maxLen int) string {
+	var from, to string
+	if minLen > 0 {
+		from = strconv.Itoa(minLen)
+	}
+	if maxLen > 0 {
+		to = strconv.Itoa(maxLen)
+	}
+	return from + "-" + to
+}
+
+// stateSummary describes a polled job state, including the running mask of
+// a mask list or increment run.
+func stateSummary(state *crackerjack.SessionState) string {
+	summary := fmt.Sprintf("%s - %.2f%%", state.Description, state.Progress)
+	switch {
+	case state.MaskCount > 1:
+		summary += fmt.Sprintf(" - mask %d/%d: %s", state.MaskIndex, state.MaskCount, state.CurrentMask)
+	case state.CurrentMask != "":
+		summary += " - mask: " + state.CurrentMask
+	}
+	return summary
+}
+
+// uploadLocalFile opens a local file and streams it with upload. The file is
+// stored under name, or under its base name if name is empty; the stored
+// name is returned.
+func uploadLocalFile(ctx context.Context, file, name string, upload func(context.Context, string, io.Reader) error) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if name == "" {
+		name = filepath.Base(file)
+	}
+	if err := upload(ctx, name, f); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// jobActions maps the job control actions to the client methods that send
+// them.
+func jobActions(client crackerjack.API) map[string]func(context.Context, int) error {
+	return map[string]func(context.Context, int) error{
+		crackerjack.ActionStop:    client.StopJob,
+		crackerjack.ActionPause:   client.PauseJob,
+		crackerjack.ActionResume:  client.ResumeJob,
+		crackerjack.ActionRestore: client.RestoreJob,
+	}
+}
+
+// maxSessionRange caps how many IDs a single "from-to" range may expand to.
+const maxSessionRange = 10000
+
+// parseSessionIDs parses one or more session IDs or ID ranges ("10-20")
+// from command arguments.
+func parseSessionIDs(args []string) ([]int, error) {
+	if len(args) == 0 {
+		return nil, errors.New("at least one session ID is required")
+	}
+	var ids []int
+	for _, arg := range args {
+		from, to, isRange := strings.Cut(arg, "-")
+		if !isRange {
+			to = from
+		}
+		first, err1 := strconv.Atoi(from)
+		last, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || first <= 0 || last < first {
+			return nil, fmt.Errorf("invalid session ID %q", arg)
+		}
+		if last-first >= maxSessionRange {
+			return nil, fmt.Errorf("session ID range %q is too large", arg)
+		}
+		for id := first; id <= last; id++ {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// selectSessions filters sessions by ID arguments, a name glob and a minimum
+// age; all given selectors must match. At least one selector is required so
+// that a bare "delete" never selects everything. It also returns how many
+// sessions were skipped because their creation time is unknown.
+func selectSessions(sessions []crackerjack.Session, idArgs []string, nameGlob string, olderThan time.Duration) ([]crackerjack.Session, int, error) {
+	if len(idArgs) == 0 && nameGlob == "" && olderThan == 0 {
+		return nil, 0, errors.New("select sessions by ID, -name or -older-than")
+	}
+	var ids map[int]bool
+	if len(idArgs) > 0 {
+		parsed, err := parseSessionIDs(idArgs)
+		if err != nil {
+			return nil, 0, err
+		}
+		ids = make(map[int]bool, len(parsed))
+		for _, id := range parsed {
+			ids[id] = true
+		}
+	}
+	if _, err := path.Match(nameGlob, ""); err != nil {
+		return nil, 0, fmt.Errorf("invalid -name pattern %q: %w", nameGlob, err)
+	}
+
+	var selected []crackerjack.Session
+	unknownAge := 0
+	cutoff := time.Now().Add(-olderThan)
+	for _, s := range sessions {
+		if ids != nil && !ids[s.ID] {
+			continue
+		}
+		if nameGlob != "" {
+			if ok, _ := path.Match(nameGlob, s.Name); !ok {
+				continue
 			}
-			break
 		}
-		time.Sleep(5 * time.Second)
+		if olderThan > 0 {
+			created, ok := s.CreatedAt()
+			if !ok {
+				unknownAge++
+				continue
+			}
+			if created.After(cutoff) {
+				continue
+			}
+		}
+		selected = append(selected, s)
+	}
+	return selected, unknownAge, nil
+}
+
+// parseAge parses a duration that may also be given in days, e.g. "30d".
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid age %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q: use a duration such as 72h or 30d", value)
+	}
+	return d, nil
+}
+
+// connectCLI resolves the server profile for a CLI command and creates an
+// API client that prints retry notices to stderr. It exits on failure.
+func connectCLI(args *cliArgs) (crackerjack.API, *Profile) {
+	_, _, profile, err := resolveProfile(args)
+	if err != nil {
+		fmt.Printf("Error loading configuration: %v\n", err)
+		os.Exit(1)
+	}
+	for _, warning := range profile.warnings() {
+		fmt.Fprintln(os.Stderr, warning)
+	}
+	client, err := newClient(profile, func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, "\n"+format+"\n", args...)
+	})
+	if err != nil {
+		fmt.Printf("Error creating API client: %v\n", err)
+		os.Exit(1)
 	}
+	return client, profile
 }
 
 // cliArgs holds the parsed command-line flags.
 type cliArgs struct {
-	interactive bool
-	sessionName string
-	hashes      string
-	hashesFile  string
-	hashType    string
-	mode        string
-	wordlist    string
-	rule        string
-	mask        string
+	interactive   bool
+	profile       string
+	url           string
+	apiKey        string
+	sessionName   string
+	hashes        string
+	hashesFile    string
+	hashesFormat  string
+	usernames     bool
+	dropInvalid   bool
+	gzip          bool
+	resumeSession int
+	hashType      string
+	mode          string
+	wordlist      string
+	wordlistFile  string
+	leftWordlist  string
+	rightWordlist string
+	leftRule      string
+	rightRule     string
+	rule          string
+	ruleFile      string
+	mask          string
+	maskFile      string
+	charsets      [4]string // custom charsets ?1 to ?4
+	increment     bool
+	incrementMin  int
+	incrementMax  int
+}
+
+// addConnectionFlags registers the flags that select and override the
+// server profile.
+func (a *cliArgs) addConnectionFlags(fs *flag.FlagSet) {
+	fs.StringVar(&a.profile, "profile", "", "Server profile to use (overrides $"+envProfile+").")
+	fs.StringVar(&a.url, "url", "", "Server URL (overrides $"+envURL+" and the profile).")
+	fs.StringVar(&a.apiKey, "api-key", "", "API key (overrides $"+envAPIKey+" and the profile).")
 }
 
 // =================================================================================
@@ -955,30 +3873,79 @@ type cliArgs struct {
 // =================================================================================
 
 func main() {
+	// --- Subcommands ---
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "config":
+			runConfigCommand(os.Args[2:])
+			return
+		case "sessions":
+			runSessionsCommand(os.Args[2:])
+			return
+		case "results":
+			runResultsCommand(os.Args[2:])
+			return
+		case "wordlists", "rules":
+			runFilesCommand(os.Args[1], os.Args[2:])
+			return
+		}
+	}
+
 	// --- Flag Definition ---
 	args := cliArgs{}
 	flag.BoolVar(&args.interactive, "i", false, "Run in interactive TUI mode.")
+	args.addConnectionFlags(flag.CommandLine)
 	flag.StringVar(&args.sessionName, "session-name", "CLI Job", "Name for the cracking session.")
 	flag.StringVar(&args.hashes, "hashes", "", "String of hashes, separated by newlines.")
 	flag.StringVar(&args.hashesFile, "hashes-file", "", "Path to a file containing hashes.")
-	flag.StringVar(&args.hashType, "hash-type", "", "Hashcat mode number (e.g., 0 for MD5).")
-	flag.StringVar(&args.mode, "mode", "wordlist", "Attack mode ('wordlist' or 'mask').")
-	flag.StringVar(&args.wordlist, "wordlist", "", "Wordlist file to use (for wordlist mode).")
+	flag.StringVar(&args.hashesFormat, "hashes-format", "", "Import the hashes from a dump: "+strings.Join(importer.Names(), ", ")+". Sets the hash type and usernames.")
+	flag.BoolVar(&args.usernames, "contains-usernames", false, "Hashes are in username:hash format.")
+	flag.BoolVar(&args.dropInvalid, "drop-invalid", false, "Drop hashes that are malformed for -hash-type without asking.")
+	flag.BoolVar(&args.gzip, "gzip", false, "Compress the hash upload.")
+	flag.IntVar(&args.resumeSession, "resume-session", 0, "Resume an interrupted hash upload into this session instead of creating a new one.")
+	flag.StringVar(&args.hashType, "hash-type", "", "Hashcat mode number (e.g., 0 for MD5), a name to search for (e.g. ntlm), or auto to detect it from the hashes.")
+	flag.StringVar(&args.mode, "mode", crackerjack.ModeWordlist, "Attack mode: "+strings.Join(crackerjack.Modes, ", ")+".")
+	flag.StringVar(&args.wordlist, "wordlist", "", "Wordlist file to use (for wordlist and hybrid modes).")
+	flag.StringVar(&args.wordlistFile, "wordlist-file", "", "Local wordlist to upload to the session and use (instead of -wordlist).")
+	flag.StringVar(&args.leftWordlist, "left-wordlist", "", "Left wordlist (for combinator mode).")
+	flag.StringVar(&args.rightWordlist, "right-wordlist", "", "Right wordlist (for combinator mode).")
+	flag.StringVar(&args.leftRule, "left-rule", "", "Rule applied to each left word, e.g. '$-' (optional, for combinator mode).")
+	flag.StringVar(&args.rightRule, "right-rule", "", "Rule applied to each right word (optional, for combinator mode).")
 	flag.StringVar(&args.rule, "rule", "", "Rules file to use (optional, for wordlist mode).")
-	flag.StringVar(&args.mask, "mask", "", "Mask to use (for mask mode).")
-	flag.Parse()
-
-	// --- Load Config and Initialize Client ---
-	config, err := loadConfig()
-	if err != nil {
-		fmt.Printf("Error loading configuration: %v\n", err)
-		os.Exit(1)
+	flag.StringVar(&args.ruleFile, "rule-file", "", "Local rules file to upload and use (instead of -rule).")
+	flag.StringVar(&args.mask, "mask", "", "Mask to use (for mask and hybrid modes).")
+	flag.StringVar(&args.maskFile, "mask-file", "", "Local .hcmask file with a list of masks to run (instead of -mask).")
+	for i := range args.charsets {
+		flag.StringVar(&args.charsets[i], strconv.Itoa(i+1), "", fmt.Sprintf("Custom charset ?%d, e.g. ?l?d (for masks).", i+1))
+	}
+	flag.BoolVar(&args.increment, "increment", false, "Run the mask at increasing lengths.")
+	flag.IntVar(&args.incrementMin, "increment-min", 0, "Shortest mask length for -increment.")
+	flag.IntVar(&args.incrementMax, "increment-max", 0, "Longest mask length for -increment.")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: cracker-client [flags]")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cracker-client config <command> ...    (manage config.json)")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cracker-client sessions <command> ...  (list, control, rename and delete sessions)")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cracker-client results [flags] <id>    (download results)")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cracker-client wordlists <command> ... (list and upload wordlists)")
+		fmt.Fprintln(flag.CommandLine.Output(), "       cracker-client rules <command> ...     (list and upload rule files)")
+		fmt.Fprintln(flag.CommandLine.Output(), "")
+		fmt.Fprintln(flag.CommandLine.Output(), "Flags:")
+		flag.PrintDefaults()
 	}
-	client := NewAPIClient(config)
+	flag.Parse()
 
 	// --- Run Mode ---
 	if args.interactive {
-		tui := NewTUIApp(client)
+		config, profileName, profile, err := resolveProfile(&args)
+		if err != nil {
+			fmt.Printf("Error loading configuration: %v\n", err)
+			os.Exit(1)
+		}
+		tui := NewTUIApp(config, profileName)
+		if err := tui.connect(profile); err != nil {
+			fmt.Printf("Error creating API client: %v\n", err)
+			os.Exit(1)
+		}
 		tui.Run()
 	} else {
 		// Basic validation for CLI mode
@@ -987,21 +3954,35 @@ func main() {
 			flag.Usage()
 			os.Exit(1)
 		}
-		if args.hashType == "" {
-			fmt.Println("Error: Must provide -hash-type for CLI mode.")
+		if args.hashType == "" && args.hashesFormat == "" {
+			fmt.Println("Error: Must provide -hash-type or -hashes-format for CLI mode.")
+			flag.Usage()
+			os.Exit(1)
+		}
+		if !slices.Contains(crackerjack.Modes, args.mode) {
+			fmt.Printf("Error: Unknown -mode %q.\n", args.mode)
+			flag.Usage()
+			os.Exit(1)
+		}
+		if crackerjack.UsesWordlist(args.mode) && args.wordlist == "" && args.wordlistFile == "" {
+			fmt.Printf("Error: Must provide -wordlist or -wordlist-file for %s mode.\n", args.mode)
 			flag.Usage()
 			os.Exit(1)
 		}
-		if args.mode == "wordlist" && args.wordlist == "" {
-			fmt.Println("Error: Must provide -wordlist for wordlist mode.")
+		if args.mode == crackerjack.ModeCombinator && (args.leftWordlist == "" || args.rightWordlist == "") {
+			fmt.Println("Error: Must provide -left-wordlist and -right-wordlist for combinator mode.")
 			flag.Usage()
 			os.Exit(1)
 		}
-		if args.mode == "mask" && args.mask == "" {
-			fmt.Println("Error: Must provide -mask for mask mode.")
+		if crackerjack.UsesMask(args.mode) && args.mask == "" && args.maskFile == "" {
+			fmt.Printf("Error: Must provide -mask or -mask-file for %s mode.\n", args.mode)
 			flag.Usage()
 			os.Exit(1)
 		}
-		runCLI(client, &args)
+		client, profile := connectCLI(&args)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		code := runCLI(ctx, client, profile, &args)
+		stop()
+		os.Exit(code)
 	}
 }
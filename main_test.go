@@ -0,0 +1,179 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEncryptAPIKey(t *testing.T) {
+	encrypted, err := encryptAPIKey("s3cret-key", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encrypted, encryptedKeyPrefix) || strings.Contains(encrypted, "s3cret-key") {
+		t.Fatalf("encryptAPIKey = %q", encrypted)
+	}
+	if key, err := decryptAPIKey(encrypted, "correct horse"); err != nil || key != "s3cret-key" {
+		t.Errorf("decryptAPIKey = %q, %v; want the key", key, err)
+	}
+
+	tests := []struct {
+		name, encrypted, passphrase string
+	}{
+		{"wrong passphrase", encrypted, "battery staple"},
+		{"unknown format", "v2:" + strings.TrimPrefix(encrypted, encryptedKeyPrefix), "correct horse"},
+		{"not base64", encryptedKeyPrefix + "!!!", "correct horse"},
+		{"truncated salt", encryptedKeyPrefix + "AAAA", "correct horse"},
+		{"truncated ciphertext", encrypted[:len(encrypted)-8], "correct horse"},
+	}
+	for _, tt := range tests {
+		if key, err := decryptAPIKey(tt.encrypted, tt.passphrase); err == nil {
+			t.Errorf("%s: decryptAPIKey = %q, want an error", tt.name, key)
+		}
+	}
+}
+
+func TestParseSessionIDs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    []int
+		wantErr bool
+	}{
+		{[]string{"12"}, []int{12}, false},
+		{[]string{"12", "13"}, []int{12, 13}, false},
+		{[]string{"40-43", "7"}, []int{40, 41, 42, 43, 7}, false},
+		{[]string{"5-5"}, []int{5}, false},
+		{nil, nil, true},
+		{[]string{"0"}, nil, true},
+		{[]string{"-3"}, nil, true},
+		{[]string{"9-3"}, nil, true},
+		{[]string{"12", "x"}, nil, true},
+		{[]string{"1-"}, nil, true},
+		{[]string{"1-10001"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSessionIDs(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseSessionIDs(%q) = %v, want an error", tt.args, got)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("parseSessionIDs(%q) = %v, %v; want %v", tt.args, got, err, tt.want)
+		}
+	}
+	if ids, err := parseSessionIDs([]string{"1-10000"}); err != nil || len(ids) != maxSessionRange {
+		t.Errorf("parseSessionIDs of the largest range = %d IDs, %v; want %d", len(ids), err, maxSessionRange)
+	}
+}
+
+func TestParseIncrement(t *testing.T) {
+	tests := []struct {
+		value            string
+		wantMin, wantMax int
+		wantErr          bool
+	}{
+		{"1-8", 1, 8, false},
+		{" 4-6 ", 4, 6, false},
+		{"3-", 3, 0, false},
+		{"-7", 0, 7, false},
+		{"-", 0, 0, false},
+		{"5-5", 5, 5, false},
+		{"8", 0, 0, true},
+		{"8-4", 0, 0, true},
+		{"0-4", 0, 0, true},
+		{"a-4", 0, 0, true},
+		{"1-b", 0, 0, true},
+	}
+	for _, tt := range tests {
+		minLen, maxLen, err := parseIncrement(tt.value)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseIncrement(%q) = %d, %d; want an error", tt.value, minLen, maxLen)
+			}
+			continue
+		}
+		if err != nil || minLen != tt.wantMin || maxLen != tt.wantMax {
+			t.Errorf("parseIncrement(%q) = %d, %d, %v; want %d, %d", tt.value, minLen, maxLen, err, tt.wantMin, tt.wantMax)
+			continue
+		}
+		if got, want := formatIncrement(minLen, maxLen), strings.TrimSpace(tt.value); got != want {
+			t.Errorf("formatIncrement(%d, %d) = %q, want %q", minLen, maxLen, got, want)
+		}
+	}
+}
+
+var testHashTypeOptions = []string{
+	"MD5 (0)",
+	"SHA1 (100)",
+	"NTLM (1000)",
+	"sha512crypt $6$, SHA512 (Unix) (1800)",
+	"bcrypt $2*$, Blowfish (Unix) (3200)",
+	"NetNTLMv1 / NetNTLMv1+ESS (5500)",
+	"NetNTLMv2 (5600)",
+	"Kerberos 5, etype 23, TGS-REP (13100)",
+	"Kerberos 5, etype 23, AS-REP (18200)",
+}
+
+func TestLookupHashType(t *testing.T) {
+	tests := []struct {
+		query      string
+		pinned     []string
+		wantMode   string
+		wantOption string
+		wantErr    bool
+	}{
+		{"1000", nil, "1000", "NTLM (1000)", false},
+		{" 1000 ", nil, "1000", "NTLM (1000)", false},
+		{"99999", nil, "99999", "", false}, // unknown modes are passed through
+		{"ntlm", nil, "1000", "NTLM (1000)", false},
+		{"NTLM (1000)", nil, "1000", "NTLM (1000)", false},
+		{"md5", nil, "0", "MD5 (0)", false},
+		{"sha1", nil, "100", "SHA1 (100)", false},
+		{"tgs", nil, "13100", "Kerberos 5, etype 23, TGS-REP (13100)", false},
+		{"as-rep", nil, "18200", "Kerberos 5, etype 23, AS-REP (18200)", false},
+		{"blowfish", nil, "3200", "bcrypt $2*$, Blowfish (Unix) (3200)", false},
+		{"netntlmv2", nil, "5600", "NetNTLMv2 (5600)", false},
+		{"kerb", nil, "", "", true},               // TGS-REP and AS-REP match equally well
+		{"kerb", []string{"18200"}, "", "", true}, // pinning does not settle a tie
+		{"netntlm", nil, "", "", true},
+		{"", nil, "", "", true},
+		{"whirlpool", nil, "", "", true},
+	}
+	for _, tt := range tests {
+		mode, option, err := lookupHashType(tt.query, testHashTypeOptions, tt.pinned)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("lookupHashType(%q) = %s, %q; want an error", tt.query, mode, option)
+			}
+			continue
+		}
+		if err != nil || mode != tt.wantMode || option != tt.wantOption {
+			t.Errorf("lookupHashType(%q) = %s, %q, %v; want %s, %q", tt.query, mode, option, err, tt.wantMode, tt.wantOption)
+		}
+	}
+}
+
+func TestSearchHashTypes(t *testing.T) {
+	tests := []struct {
+		query  string
+		pinned []string
+		want   []string
+	}{
+		{"", nil, testHashTypeOptions},
+		{"", []string{"5600", "1000"}, []string{"NetNTLMv2 (5600)", "NTLM (1000)"}},
+		{"kerb", nil, []string{"Kerberos 5, etype 23, TGS-REP (13100)", "Kerberos 5, etype 23, AS-REP (18200)"}},
+		{"kerb", []string{"18200"}, []string{"Kerberos 5, etype 23, AS-REP (18200)", "Kerberos 5, etype 23, TGS-REP (13100)"}},
+		{"ntlm", nil, []string{"NTLM (1000)", "NetNTLMv1 / NetNTLMv1+ESS (5500)", "NetNTLMv2 (5600)"}},
+		{"131", nil, []string{"Kerberos 5, etype 23, TGS-REP (13100)"}},
+		{"scrypt", nil, []string{"sha512crypt $6$, SHA512 (Unix) (1800)"}},
+		{"nope", nil, []string{}},
+	}
+	for _, tt := range tests {
+		if got := searchHashTypes(tt.query, testHashTypeOptions, tt.pinned); !slices.Equal(got, tt.want) {
+			t.Errorf("searchHashTypes(%q, pinned %v) = %q, want %q", tt.query, tt.pinned, got, tt.want)
+		}
+	}
+}
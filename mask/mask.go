@@ -0,0 +1,201 @@
+// Package mask parses hashcat masks and computes their keyspace, so that the
+// size of a mask attack can be judged before it is started.
+package mask
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// builtin holds hashcat's built-in charsets by their letter. ?a and ?b are
+// added by init.
+var builtin = map[byte]string{
+	'l': "abcdefghijklmnopqrstuvwxyz",
+	'u': "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	'd': "0123456789",
+	'h': "0123456789abcdef",
+	'H': "0123456789ABCDEF",
+	's': " !\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~",
+}
+
+func init() {
+	builtin['a'] = builtin['l'] + builtin['u'] + builtin['d'] + builtin['s']
+	var all strings.Builder
+	for c := 0; c < 256; c++ {
+		all.WriteByte(byte(c))
+	}
+	builtin['b'] = all.String()
+}
+
+// charset is a set of bytes.
+type charset [256]bool
+
+func (c *charset) size() int {
+	n := 0
+	for _, ok := range c {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// expand resolves a custom charset definition, made of literal characters
+// and built-in "?x" charsets, into a set of bytes.
+func expand(def string) (charset, error) {
+	var set charset
+	for i := 0; i < len(def); i++ {
+		if def[i] != '?' {
+			set[def[i]] = true
+			continue
+		}
+		if i+1 == len(def) {
+			return set, errors.New("charset ends with a lone '?'")
+		}
+		i++
+		if def[i] == '?' {
+			set['?'] = true
+			continue
+		}
+		chars, ok := builtin[def[i]]
+		if !ok {
+			return set, fmt.Errorf("unknown charset ?%c", def[i])
+		}
+		for j := 0; j < len(chars); j++ {
+			set[chars[j]] = true
+		}
+	}
+	return set, nil
+}
+
+// Positions returns the charset size of each position of mask, using the
+// custom charsets ?1 to ?4 in custom.
+func Positions(mask string, custom []string) ([]int, error) {
+	if mask == "" {
+		return nil, errors.New("mask is empty")
+	}
+	if len(custom) > 4 {
+		return nil, fmt.Errorf("at most 4 custom charsets can be defined, got %d", len(custom))
+	}
+	customSizes := make([]int, len(custom))
+	for i, def := range custom {
+		if def == "" {
+			continue
+		}
+		set, err := expand(def)
+		if err != nil {
+			return nil, fmt.Errorf("custom charset ?%d: %w", i+1, err)
+		}
+		customSizes[i] = set.size()
+	}
+
+	var sizes []int
+	for i := 0; i < len(mask); i++ {
+		if mask[i] != '?' {
+			sizes = append(sizes, 1)
+			continue
+		}
+		if i+1 == len(mask) {
+			return nil, errors.New("mask ends with a lone '?'")
+		}
+		i++
+		c := mask[i]
+		switch {
+		case c == '?':
+			sizes = append(sizes, 1)
+		case c >= '1' && c <= '4':
+			n := int(c - '1')
+			if n >= len(customSizes) || customSizes[n] == 0 {
+				return nil, fmt.Errorf("custom charset ?%c is used but not defined", c)
+			}
+			sizes = append(sizes, customSizes[n])
+		default:
+			chars, ok := builtin[c]
+			if !ok {
+				return nil, fmt.Errorf("unknown charset ?%c at position %d", c, len(sizes)+1)
+			}
+			sizes = append(sizes, len(chars))
+		}
+	}
+	return sizes, nil
+}
+
+// Keyspace returns the number of candidates of mask. With increment set, the
+// candidates of every length from minLen to maxLen are added up; zero bounds
+// default to 1 and the mask length, as in hashcat.
+func Keyspace(mask string, custom []string, increment bool, minLen, maxLen int) (*big.Int, error) {
+	sizes, err := Positions(mask, custom)
+	if err != nil {
+		return nil, err
+	}
+	if !increment {
+		minLen, maxLen = len(sizes), len(sizes)
+	}
+	if minLen <= 0 {
+		minLen = 1
+	}
+	if maxLen <= 0 || maxLen > len(sizes) {
+		maxLen = len(sizes)
+	}
+	if minLen > maxLen {
+		return nil, fmt.Errorf("increment minimum %d exceeds the mask length %d", minLen, len(sizes))
+	}
+
+	total := new(big.Int)
+	product := big.NewInt(1)
+	for length, size := range sizes {
+		product.Mul(product, big.NewInt(int64(size)))
+		if length+1 >= minLen && length+1 <= maxLen {
+			total.Add(total, product)
+		}
+	}
+	return total, nil
+}
+
+// ParseLine splits a line of a .hcmask file into its mask and the custom
+// charsets defined before it, e.g. "?l?d,?u,?2?1?1" has charsets "?l?d"
+// and "?u".
+func ParseLine(line string) (mask string, custom []string, err error) {
+	if line == "" {
+		return "", nil, errors.New("mask line is empty")
+	}
+	fields := SplitCharsets(line)
+	if len(fields) > 5 {
+		return "", nil, fmt.Errorf("too many fields in mask line %q", line)
+	}
+	return fields[len(fields)-1], fields[:len(fields)-1], nil
+}
+
+// SplitCharsets splits comma-separated custom charsets, in which "\," is a
+// literal comma, as in .hcmask files. An empty string yields no charsets.
+func SplitCharsets(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var charsets []string
+	var current strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == ',':
+			current.WriteByte(',')
+			i++
+		case s[i] == ',':
+			charsets = append(charsets, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(s[i])
+		}
+	}
+	return append(charsets, current.String())
+}
+
+// JoinCharsets reverses SplitCharsets.
+func JoinCharsets(charsets []string) string {
+	escaped := make([]string, len(charsets))
+	for i, charset := range charsets {
+		escaped[i] = strings.ReplaceAll(charset, ",", `\,`)
+	}
+	return strings.Join(escaped, ",")
+}
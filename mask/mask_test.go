@@ -0,0 +1,124 @@
+package mask
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestKeyspace(t *testing.T) {
+	tests := []struct {
+		mask           string
+		custom         []string
+		increment      bool
+		minLen, maxLen int
+		want           string // decimal, or "" for an error
+	}{
+		{"?d?d?d?d", nil, false, 0, 0, "10000"},
+		{"?l?u", nil, false, 0, 0, "676"},
+		{"?a", nil, false, 0, 0, "95"},
+		{"?s", nil, false, 0, 0, "33"},
+		{"?h?H", nil, false, 0, 0, "256"},
+		{"?b", nil, false, 0, 0, "256"},
+		{"Summer", nil, false, 0, 0, "1"},
+		{"??", nil, false, 0, 0, "1"},
+		{"?1?1", []string{"?l?d"}, false, 0, 0, "1296"},
+		{"?1", []string{"abc?d"}, false, 0, 0, "13"},
+		{"?1", []string{"aab"}, false, 0, 0, "2"},
+		{"?1", []string{"??"}, false, 0, 0, "1"},
+		{"?2", []string{"", "?u"}, false, 0, 0, "26"},
+		{"?u?l?l?l?l?l?l?l?d?d?d?d?d?d", nil, false, 0, 0, "208827064576000000"},
+
+		// Increment adds up every length, from 1 to the mask length by default.
+		{"?d?d?d", nil, true, 0, 0, "1110"},
+		{"?d?d?d", nil, true, 2, 0, "1100"},
+		{"?d?d?d", nil, true, 0, 2, "110"},
+		{"?d?d?d", nil, true, 2, 9, "1100"},
+		{"?d?d?d", nil, false, 1, 1, "1000"},
+
+		{"", nil, false, 0, 0, ""},
+		{"?", nil, false, 0, 0, ""},
+		{"?d?", nil, false, 0, 0, ""},
+		{"?z", nil, false, 0, 0, ""},
+		{"?1", nil, false, 0, 0, ""},
+		{"?3", []string{"?d"}, false, 0, 0, ""},
+		{"?1", []string{"?q"}, false, 0, 0, ""},
+		{"?1", []string{"a", "b", "c", "d", "e"}, false, 0, 0, ""},
+		{"?d?d", nil, true, 3, 0, ""},
+	}
+	for _, tt := range tests {
+		got, err := Keyspace(tt.mask, tt.custom, tt.increment, tt.minLen, tt.maxLen)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("Keyspace(%q, %q, %v, %d, %d) = %v, want an error", tt.mask, tt.custom, tt.increment, tt.minLen, tt.maxLen, got)
+		case tt.want != "" && err != nil:
+			t.Errorf("Keyspace(%q, %q, %v, %d, %d) failed: %v", tt.mask, tt.custom, tt.increment, tt.minLen, tt.maxLen, err)
+		case tt.want != "" && got.String() != tt.want:
+			t.Errorf("Keyspace(%q, %q, %v, %d, %d) = %v, want %s", tt.mask, tt.custom, tt.increment, tt.minLen, tt.maxLen, got, tt.want)
+		}
+	}
+}
+
+func TestPositions(t *testing.T) {
+	got, err := Positions("a?d?1??", []string{"xyz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, 10, 3, 1}; !slices.Equal(got, want) {
+		t.Errorf("Positions = %v, want %v", got, want)
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line       string
+		wantMask   string
+		wantCustom []string
+		wantErr    bool
+	}{
+		{"?d?d?d", "?d?d?d", []string{}, false},
+		{"?l?d,?u,?2?1?1", "?2?1?1", []string{"?l?d", "?u"}, false},
+		{`a\,b,?1?1`, "?1?1", []string{"a,b"}, false},
+		{`?d,Year\,?1`, `Year,?1`, []string{"?d"}, false},
+		{"?d,,?1?2", "?1?2", []string{"?d", ""}, false},
+		{"a,b,c,d,?1?2?3?4", "?1?2?3?4", []string{"a", "b", "c", "d"}, false},
+		{"a,b,c,d,e,?1", "", nil, true},
+		{"", "", nil, true},
+	}
+	for _, tt := range tests {
+		mask, custom, err := ParseLine(tt.line)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseLine(%q) = %q, %q, want an error", tt.line, mask, custom)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseLine(%q) failed: %v", tt.line, err)
+			continue
+		}
+		if mask != tt.wantMask || !slices.Equal(custom, tt.wantCustom) {
+			t.Errorf("ParseLine(%q) = %q, %q, want %q, %q", tt.line, mask, custom, tt.wantMask, tt.wantCustom)
+		}
+	}
+}
+
+func TestSplitJoinCharsets(t *testing.T) {
+	tests := []struct {
+		text     string
+		charsets []string
+	}{
+		{"", nil},
+		{"?l?d", []string{"?l?d"}},
+		{"?l?d,?u", []string{"?l?d", "?u"}},
+		{`?d\,.,?s`, []string{"?d,.", "?s"}},
+		{",?u", []string{"", "?u"}},
+	}
+	for _, tt := range tests {
+		if got := SplitCharsets(tt.text); !slices.Equal(got, tt.charsets) {
+			t.Errorf("SplitCharsets(%q) = %q, want %q", tt.text, got, tt.charsets)
+		}
+		if got := JoinCharsets(tt.charsets); got != tt.text {
+			t.Errorf("JoinCharsets(%q) = %q, want %q", tt.charsets, got, tt.text)
+		}
+	}
+}